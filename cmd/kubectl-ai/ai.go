@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yourusername/kubectl-ai/pkg/session"
+)
+
+// newCmdCommand 创建 cmd 子命令：将自然语言转换为 kubectl 命令并执行
+func newCmdCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "cmd <自然语言描述>",
+		Short: "将自然语言转换为 kubectl 命令并执行",
+		Long:  "将自然语言转换为 kubectl 命令，必要时先执行只读命令收集集群信息，确认后执行最终命令。描述为 - 时从标准输入读取，此时无法回答确认提示，需要配合 --yes 或 --dry-run 使用。",
+		Example: `  kubectl ai cmd "查看所有命名空间中未就绪的 pod"
+  kubectl ai cmd -n payments --dry-run "把 api 扩容到 3 个副本"
+  echo "查看 default 命名空间中重启次数最多的 pod" | kubectl ai cmd -y -`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		Annotations:       map[string]string{structuredAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			input, err := readInput(args)
+			if err != nil {
+				return err
+			}
+			rt, err := a.newRuntime()
+			if err != nil {
+				return err
+			}
+
+			// 转换命令，收集必要信息后执行并获取输出
+			rep, err := rt.run(cmd.Context(), a.newConversation(), input)
+			if a.structured() {
+				if werr := a.emit(rep); werr != nil {
+					return werr
+				}
+				return err
+			}
+			if err != nil {
+				return err
+			}
+
+			// 如果有输出，直接打印
+			if rep.Output != "" {
+				fmt.Fprintf(a.out, "\n%s\n", rep.Output)
+			}
+			return nil
+		},
+	}
+}
+
+// newExplainCommand 创建 explain 子命令：解释 kubectl 命令、YAML 等的含义
+func newExplainCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "explain <命令或内容>",
+		Short: "解释 kubectl 命令、YAML 等的含义",
+		Long:  "解释 kubectl 命令、YAML 等的含义，内容为 - 时从标准输入读取。",
+		Example: `  kubectl ai explain "kubectl get pods -n kube-system"
+  kubectl ai explain - < deployment.yaml`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		Annotations:       map[string]string{structuredAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			input, err := readInput(args)
+			if err != nil {
+				return err
+			}
+			rt, err := a.newRuntime()
+			if err != nil {
+				return err
+			}
+
+			// 调用大模型解释命令
+			text, err := rt.client.ExplainCommand(cmd.Context(), input, func(content string) {
+				fmt.Fprint(a.out, content)
+			})
+			if err != nil {
+				err = fmt.Errorf("failed to explain command: %w", err)
+			}
+			if a.structured() {
+				result := &explanation{Input: input, Explanation: text, Usage: rt.tracker.Session()}
+				if err != nil {
+					result.Error, result.ExitCode = err.Error(), exitCode(err)
+				}
+				if werr := a.emit(result); werr != nil {
+					return werr
+				}
+				return err
+			}
+			if err != nil {
+				return err
+			}
+
+			// 打印解释
+			fmt.Fprintln(a.out, text)
+			return nil
+		},
+	}
+}
+
+// newExecCommand 创建 exec 子命令：进入交互模式
+func newExecCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:   "exec",
+		Short: "进入交互模式",
+		Long:  "进入交互模式，连续输入自然语言问题，支持行编辑、输入历史和 /ns、/model 等斜杠命令（输入 /help 查看）。执行中按 Ctrl-C 只取消当前请求。会话保存在本地，退出后可以使用 sessions resume 继续。",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rt, err := a.newRuntime()
+			if err != nil {
+				return err
+			}
+			return a.runInteractive(cmd.Context(), rt, session.New())
+		},
+	}
+}
+
+// readInput 返回命令行参数组成的输入，参数只有 - 时从标准输入读取
+func readInput(args []string) (string, error) {
+	if len(args) != 1 || args[0] != "-" {
+		return strings.Join(args, " "), nil
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %v", err)
+	}
+	input := strings.TrimSpace(string(data))
+	if input == "" {
+		return "", fmt.Errorf("no input on stdin")
+	}
+	return input, nil
+}
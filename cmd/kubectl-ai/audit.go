@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yourusername/kubectl-ai/pkg/utils"
+)
+
+// newAuditCommand 创建 audit 子命令：校验审计日志的哈希链
+func newAuditCommand(a *app) *cobra.Command {
+	audit := &cobra.Command{
+		Use:   "audit",
+		Short: "管理审计日志",
+	}
+
+	verify := &cobra.Command{
+		Use:   "verify",
+		Short: "校验审计日志的哈希链是否完整",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			auditLog, err := a.newAuditLogger()
+			if err != nil {
+				return err
+			}
+			result, err := auditLog.Verify()
+			if err != nil {
+				return fmt.Errorf("%s审计日志校验失败（已校验 %d 条记录）：%v", utils.Red("[审计] "), result.Records, err)
+			}
+			fmt.Printf("%s审计日志完整：%d 个文件，%d 条记录\n", utils.Green("[审计] "), result.Files, result.Records)
+			if result.Anchor != "" {
+				fmt.Printf("更早的记录已被轮转删除，哈希链起点为 %s\n", result.Anchor)
+			}
+			return nil
+		},
+	}
+
+	audit.AddCommand(verify)
+	return audit
+}
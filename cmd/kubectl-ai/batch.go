@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yourusername/kubectl-ai/pkg/usage"
+	"github.com/yourusername/kubectl-ai/pkg/utils"
+)
+
+// batchRequest 是批量文件中的一个请求及其所在的行号
+type batchRequest struct {
+	line  int
+	input string
+}
+
+// batchResult 是 batch 子命令的结构化结果：每个已执行请求的结果，以及汇总的数量和 token 用量
+type batchResult struct {
+	Results   []*report    `json:"results" yaml:"results"`
+	Succeeded int          `json:"succeeded" yaml:"succeeded"`
+	Failed    int          `json:"failed" yaml:"failed"`
+	Skipped   int          `json:"skipped" yaml:"skipped"`
+	Usage     usage.Totals `json:"usage" yaml:"usage"`
+	ExitCode  int          `json:"exit_code" yaml:"exit_code"`
+}
+
+// newBatchCommand 创建 batch 子命令：依次或并发执行文件中的多个自然语言请求
+func newBatchCommand(a *app) *cobra.Command {
+	var concurrency int
+	var failFast bool
+	batch := &cobra.Command{
+		Use:   "batch <文件>",
+		Short: "依次或并发执行文件中的多个自然语言请求",
+		Long: `文件中每行一个自然语言请求，忽略空行和以 # 开头的注释行；文件为 - 时从标准输入读取。
+每个请求相互独立，不共享对话历史，各自产生一条结果记录。有请求失败时以第一个失败请求的退出码退出。`,
+		Example: `  kubectl ai batch runbook.txt --dry-run
+  kubectl ai batch regression.txt -c 4 -y -o json > results.json`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{structuredAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if concurrency < 1 {
+				return fmt.Errorf("--concurrency must be at least 1")
+			}
+			requests, err := readBatch(args[0])
+			if err != nil {
+				return err
+			}
+			rt, err := a.newRuntime()
+			if err != nil {
+				return err
+			}
+
+			result, err := a.runBatch(cmd.Context(), rt, requests, concurrency, failFast)
+			if a.structured() {
+				if werr := a.emit(result); werr != nil {
+					return werr
+				}
+				return err
+			}
+			fmt.Fprintf(a.out, "\n共 %d 个请求：成功 %d，失败 %d，跳过 %d\n", len(requests), result.Succeeded, result.Failed, result.Skipped)
+			fmt.Fprint(a.out, rt.tracker.Summary())
+			return err
+		},
+	}
+	batch.Flags().IntVarP(&concurrency, "concurrency", "c", 1, "同时执行的请求数")
+	batch.Flags().BoolVar(&failFast, "fail-fast", false, "有请求失败后不再开始新的请求")
+	return batch
+}
+
+// readBatch 读取批量文件中的请求，path 为 - 时从标准输入读取
+func readBatch(path string) ([]batchRequest, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open batch file: %v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var requests []batchRequest
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		input := strings.TrimSpace(scanner.Text())
+		if input == "" || strings.HasPrefix(input, "#") {
+			continue
+		}
+		requests = append(requests, batchRequest{line: line, input: input})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch file: %v", err)
+	}
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("no requests in %s", path)
+	}
+	return requests, nil
+}
+
+// runBatch 使用 concurrency 个执行器执行请求，结果按请求在文件中的顺序排列；
+// 有请求失败时返回的错误包含第一个失败请求的错误，用于决定退出码
+func (a *app) runBatch(ctx context.Context, rt *runtime, requests []batchRequest, concurrency int, failFast bool) (*batchResult, error) {
+	if concurrency > len(requests) {
+		concurrency = len(requests)
+	}
+	// 每个并发执行的请求使用独立的执行器和 Agent
+	workers := make([]*runtime, concurrency)
+	for i := range workers {
+		worker, err := a.worker(rt)
+		if err != nil {
+			return nil, err
+		}
+		workers[i] = worker
+	}
+
+	reports := make([]*report, len(requests))
+	errs := make([]error, len(requests))
+	jobs := make(chan int)
+	var failed atomic.Bool
+	var printMu sync.Mutex
+	var wg sync.WaitGroup
+	for _, worker := range workers {
+		wg.Add(1)
+		go func(worker *runtime) {
+			defer wg.Done()
+			for i := range jobs {
+				// 有请求失败后跳过尚未开始的请求
+				if failFast && failed.Load() {
+					continue
+				}
+				req := requests[i]
+				printMu.Lock()
+				fmt.Fprintf(a.out, "\n%s[%d/%d] 第 %d 行：%s\n", utils.Blue("[请求] "), i+1, len(requests), req.line, req.input)
+				printMu.Unlock()
+
+				rep, err := worker.run(ctx, nil, req.input)
+				rep.Line = req.line
+				reports[i], errs[i] = rep, err
+				if err != nil {
+					failed.Store(true)
+				}
+
+				printMu.Lock()
+				if err != nil {
+					fmt.Fprintf(a.out, "%s[%d/%d] %v\n", utils.Red("[失败] "), i+1, len(requests), err)
+				} else if rep.Output != "" {
+					fmt.Fprintf(a.out, "\n%s\n", rep.Output)
+				}
+				printMu.Unlock()
+			}
+		}(worker)
+	}
+	for i := range requests {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	result := &batchResult{Results: []*report{}, Usage: rt.tracker.Session()}
+	var firstErr error
+	for i, rep := range reports {
+		switch {
+		case rep == nil:
+			result.Skipped++
+			continue
+		case errs[i] != nil:
+			result.Failed++
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+		default:
+			result.Succeeded++
+		}
+		result.Results = append(result.Results, rep)
+	}
+	if firstErr == nil {
+		return result, nil
+	}
+	err := fmt.Errorf("%d of %d requests failed: %w", result.Failed, len(requests), firstErr)
+	result.ExitCode = exitCode(err)
+	return result, err
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yourusername/kubectl-ai/pkg/kubectl"
+	"github.com/yourusername/kubectl-ai/pkg/session"
+)
+
+// pluginCompletionName 是 kubectl 为插件补全查找的可执行文件名，链接到本程序即可支持 kubectl ai <TAB>
+const pluginCompletionName = "kubectl_complete-ai"
+
+// completionTimeout 是补全时调用 kubectl 查询集群的超时时间，避免集群不可达时卡住 shell
+const completionTimeout = 5 * time.Second
+
+// completionArgs 在以 kubectl_complete-ai 调用时把参数转为 cobra 的补全请求
+func completionArgs(args []string) []string {
+	if filepath.Base(args[0]) != pluginCompletionName {
+		return args[1:]
+	}
+	return append([]string{cobra.ShellCompRequestCmd}, args[1:]...)
+}
+
+// skipConfig 让命令只校验输出格式而不加载配置，用于生成补全脚本、查看版本等不需要大模型和集群的命令
+func skipConfig(a *app, cmd *cobra.Command) *cobra.Command {
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error { return a.setOutput(cmd) }
+	return cmd
+}
+
+// registerCompletions 注册全局参数的动态补全，并让生成补全脚本的命令不加载配置
+func registerCompletions(root *cobra.Command, a *app) {
+	root.RegisterFlagCompletionFunc("context", a.completeContexts)
+	root.RegisterFlagCompletionFunc("namespace", a.completeNamespaces)
+	root.RegisterFlagCompletionFunc("output", cobra.FixedCompletions([]string{outputText, outputJSON, outputYAML}, cobra.ShellCompDirectiveNoFileComp))
+	root.RegisterFlagCompletionFunc("model", cobra.NoFileCompletions)
+
+	root.InitDefaultCompletionCmd()
+	if completion, _, err := root.Find([]string{"completion"}); err == nil && completion != root {
+		skipConfig(a, completion)
+		completion.Short = "生成 bash、zsh、fish 或 powershell 的补全脚本"
+	}
+}
+
+// kubectlNames 执行 kubectl 查询并返回每行一个的名称，失败时返回空
+func (a *app) kubectlNames(args ...string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	if a.opts.kubeconfig != "" {
+		cmd.Env = append(os.Environ(), "KUBECONFIG="+a.opts.kubeconfig)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(out))
+}
+
+// contexts 返回 kubeconfig 中的 context 名称
+func (a *app) contexts() []string {
+	return a.kubectlNames("config", "get-contexts", "-o", "name")
+}
+
+// namespaces 返回集群中的命名空间，kubeContext 为空时查询当前 context
+func (a *app) namespaces(kubeContext string) []string {
+	query := []string{"get", "namespaces", "-o", "name", "--request-timeout", completionTimeout.String()}
+	if kubeContext != "" {
+		query = append(query, "--context", kubeContext)
+	}
+	var names []string
+	for _, name := range a.kubectlNames(query...) {
+		names = append(names, strings.TrimPrefix(name, "namespace/"))
+	}
+	return names
+}
+
+// completeContexts 补全 kubeconfig 中的 context 名称
+func (a *app) completeContexts(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return a.contexts(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeNamespaces 补全集群中的命名空间，命令行已指定 --context 时查询该 context
+func (a *app) completeNamespaces(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return a.namespaces(a.opts.kubeContext), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSessions 补全保存的会话 ID，以会话的第一条输入作为说明
+func (a *app) completeSessions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || a.load(cmd) != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	all, err := session.NewStore(a.cfg.DataDir).List()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var ids []string
+	for _, sess := range all {
+		ids = append(ids, cobra.CompletionWithDesc(sess.ID, sess.Title()))
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeJournal 补全撤销日志中的记录 ID，以执行的命令作为说明，最近的记录在前
+func (a *app) completeJournal(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || a.load(cmd) != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	entries, err := kubectl.NewJournal(a.cfg.DataDir).List()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var ids []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		ids = append(ids, cobra.CompletionWithDesc(strconv.Itoa(entries[i].ID), entries[i].Command))
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yourusername/kubectl-ai/pkg/kubectl"
+)
+
+// newHistoryCommand 创建 history 子命令：列出撤销日志中的记录，指定 id 时显示该记录的快照
+func newHistoryCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:               "history [id]",
+		Short:             "列出写操作的撤销记录，或查看指定记录的快照",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: a.completeJournal,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			journal := kubectl.NewJournal(a.cfg.DataDir)
+			if len(args) == 0 {
+				return printJournal(journal)
+			}
+
+			id, err := parseJournalID(args[0])
+			if err != nil {
+				return err
+			}
+			entry, err := journal.Get(id)
+			if err != nil {
+				return fmt.Errorf("failed to read journal: %v", err)
+			}
+			fmt.Printf("#%d %s\n", entry.ID, entry.Time.Format("2006-01-02 15:04:05"))
+			fmt.Printf("Context: %s\n命名空间: %s\n命令: %s\n", entry.Context, entry.Namespace, entry.Command)
+			if len(entry.Absent) > 0 {
+				fmt.Println("执行前不存在的对象（撤销时删除）:")
+				for _, ref := range entry.Absent {
+					fmt.Printf("  %s\n", ref)
+				}
+			}
+			fmt.Printf("\n%s", entry.Snapshot)
+			return nil
+		},
+	}
+}
+
+// newUndoCommand 创建 undo 子命令：恢复撤销日志中指定记录的快照，未指定 id 时恢复最近一条
+func newUndoCommand(a *app) *cobra.Command {
+	return &cobra.Command{
+		Use:               "undo [id]",
+		Short:             "恢复写操作执行前的快照，未指定 id 时恢复最近一条",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: a.completeJournal,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id := 0
+			if len(args) > 0 {
+				var err error
+				if id, err = parseJournalID(args[0]); err != nil {
+					return err
+				}
+			}
+
+			executor, err := a.newExecutor()
+			if err != nil {
+				return err
+			}
+			// 撤销与普通命令一样写入审计日志
+			if a.cfg.AuditEnabled {
+				auditLog, err := a.newAuditLogger()
+				if err != nil {
+					return err
+				}
+				executor.SetAudit(auditLog)
+			}
+			if err := executor.Undo(cmd.Context(), id); err != nil {
+				return fmt.Errorf("failed to undo command: %v", err)
+			}
+			return nil
+		},
+	}
+}
+
+// printJournal 列出撤销日志中的全部记录
+func printJournal(journal *kubectl.Journal) error {
+	entries, err := journal.List()
+	if err != nil {
+		return fmt.Errorf("failed to read journal: %v", err)
+	}
+	if len(entries) == 0 {
+		fmt.Println("没有历史记录")
+		return nil
+	}
+	for _, entry := range entries {
+		snapshot := "无快照"
+		if entry.Undoable() {
+			snapshot = "有快照"
+		}
+		fmt.Printf("#%-4d %s  %-20s %-16s %s  %s\n", entry.ID, entry.Time.Format("2006-01-02 15:04:05"),
+			entry.Context, entry.Namespace, snapshot, entry.Command)
+	}
+	return nil
+}
+
+// parseJournalID 解析撤销记录的 ID
+func parseJournalID(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id: %s", arg)
+	}
+	return id, nil
+}
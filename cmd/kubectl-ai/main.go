@@ -1,153 +1,251 @@
 package main
 
 import (
-	"context"
 	"fmt"
+	"io"
 	"os"
-	"strings"
-	"bufio"
+	"path/filepath"
 
+	"github.com/spf13/cobra"
+
+	"github.com/yourusername/kubectl-ai/pkg/agent"
+	"github.com/yourusername/kubectl-ai/pkg/audit"
 	"github.com/yourusername/kubectl-ai/pkg/config"
-	"github.com/yourusername/kubectl-ai/pkg/deepseek"
 	"github.com/yourusername/kubectl-ai/pkg/kubectl"
+	"github.com/yourusername/kubectl-ai/pkg/llm"
+	"github.com/yourusername/kubectl-ai/pkg/provider"
+	"github.com/yourusername/kubectl-ai/pkg/usage"
+	"github.com/yourusername/kubectl-ai/pkg/utils"
 )
 
 func main() {
-	// 加载配置
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
-		os.Exit(1)
-	}
-
-	// 检查命令行参数
-	if len(os.Args) < 3 {
-		fmt.Println("Usage: kubectl ai <cmd|explain> \"<natural language command>\"")
-		os.Exit(1)
+	root := newRootCommand()
+	root.SetArgs(completionArgs(os.Args))
+	if err := root.Execute(); err != nil {
+		fmt.Fprintf(root.OutOrStdout(), "Error: %v\n", err)
+		os.Exit(exitCode(err))
 	}
+}
 
-	// 获取子命令和自然语言命令
-	subCommand := os.Args[1]
-	naturalCommand := strings.Join(os.Args[2:], " ")
+// globalOptions 是所有子命令共用的全局参数
+type globalOptions struct {
+	configPath  string
+	kubeContext string
+	namespace   string
+	kubeconfig  string
+	yes         bool
+	dryRun      bool
+	model       string
+	output      string
+	noColor     bool
+}
 
-	// 创建上下文
-	ctx := context.Background()
+// app 保存全局参数以及根据它们加载的配置
+type app struct {
+	opts globalOptions
+	cfg  *config.Config
+	// stdout 是输出结构化结果的标准输出
+	stdout io.Writer
+	// out 是提示、确认与执行过程等面向用户的输出，使用 json 或 yaml 输出时为标准错误，标准输出只用于结构化结果
+	out io.Writer
+}
 
-	// 创建 DeepSeek 客户端
-	client := deepseek.NewClient(cfg.DeepseekAPIKey, cfg.EnableChat)
+// newRootCommand 创建根命令并注册全局参数和各子命令
+func newRootCommand() *cobra.Command {
+	a := &app{stdout: os.Stdout, out: os.Stdout}
+	root := &cobra.Command{
+		Use:   "kubectl-ai",
+		Short: "使用自然语言操作 Kubernetes 的 kubectl 插件",
+		Long: `kubectl-ai 将自然语言转换为 kubectl 命令，先收集必要的集群信息，
+再经过风险评估、策略检查和确认后执行。`,
+		Annotations: map[string]string{
+			cobra.CommandDisplayNameAnnotation: "kubectl ai",
+		},
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// 补全请求只在补全会话、撤销记录等需要数据目录时才加载配置
+			if cmd.Name() == cobra.ShellCompRequestCmd {
+				return nil
+			}
+			return a.load(cmd)
+		},
+	}
 
-	// 创建 kubectl 执行器
-	executor := kubectl.NewExecutor(cfg.AutoExecute)
+	flags := root.PersistentFlags()
+	flags.StringVar(&a.opts.configPath, "config", "", "配置文件路径，默认依次查找 ./config.yaml 和 ../config.yaml")
+	flags.StringVar(&a.opts.kubeContext, "context", "", "命令默认使用的 kube-context")
+	flags.StringVarP(&a.opts.namespace, "namespace", "n", "", "命令默认作用的命名空间")
+	flags.StringVar(&a.opts.kubeconfig, "kubeconfig", "", "kubeconfig 文件路径")
+	flags.BoolVarP(&a.opts.yes, "yes", "y", false, "自动确认执行和修正命令，受保护的目标与 confirm 策略规则仍需确认")
+	flags.BoolVar(&a.opts.dryRun, "dry-run", false, "只执行只读命令，其余命令只显示并预演，不会执行")
+	flags.StringVar(&a.opts.model, "model", "", "覆盖所有用途使用的模型")
+	flags.StringVarP(&a.opts.output, "output", "o", outputText, "输出格式：text、json 或 yaml，json 与 yaml 只用于 cmd、explain 和 batch")
+	flags.BoolVar(&a.opts.noColor, "no-color", false, "不输出 ANSI 颜色，也可以设置环境变量 NO_COLOR")
+
+	root.AddCommand(
+		newCmdCommand(a),
+		newExplainCommand(a),
+		newExecCommand(a),
+		newBatchCommand(a),
+		newSessionsCommand(a),
+		newPolicyCommand(a),
+		newHistoryCommand(a),
+		newUndoCommand(a),
+		newAuditCommand(a),
+		newVersionCommand(a),
+	)
+	registerCompletions(root, a)
+	return root
+}
 
-	// 根据子命令执行不同的操作
-	switch subCommand {
-	case "cmd":
-		// 调用 DeepSeek API 转换命令
-		kubectlCommand, err := client.TranslateCommand(ctx, naturalCommand)
-		if err != nil {
-			fmt.Printf("Error translating command: %v\n", err)
-			os.Exit(1)
+// setOutput 校验 --output 并确定面向用户的输出位置，不加载配置的命令同样需要校验
+func (a *app) setOutput(cmd *cobra.Command) error {
+	switch a.opts.output {
+	case outputText:
+	case outputJSON, outputYAML:
+		if cmd.Annotations[structuredAnnotation] == "" {
+			return fmt.Errorf("%s does not support --output %s", cmd.CommandPath(), a.opts.output)
 		}
+		// 标准输出只用于结构化结果，执行过程中的提示与确认信息改为输出到标准错误
+		a.out = os.Stderr
+	default:
+		return fmt.Errorf("unsupported output format: %s", a.opts.output)
+	}
+	cmd.Root().SetOut(a.out)
+	utils.SetColor(!a.structured() && !a.opts.noColor && os.Getenv("NO_COLOR") == "")
+	return nil
+}
 
-		// 执行命令并获取输出
-		output, err := executor.ExecuteNaturalCommand(ctx, kubectlCommand)
-		if err != nil {
-			fmt.Printf("Error executing command: %v\n", err)
-			os.Exit(1)
-		}
+// load 校验输出格式并加载配置，用全局参数覆盖其中的对应项
+func (a *app) load(cmd *cobra.Command) error {
+	if err := a.setOutput(cmd); err != nil {
+		return err
+	}
 
-		// 如果有输出，直接打印
-		if output != "" {
-			fmt.Printf("\n%s\n", output)
+	// kubectl 子进程都通过 KUBECONFIG 使用指定的 kubeconfig
+	if a.opts.kubeconfig != "" {
+		if err := os.Setenv("KUBECONFIG", a.opts.kubeconfig); err != nil {
+			return fmt.Errorf("failed to set KUBECONFIG: %v", err)
 		}
+	}
 
-	case "explain":
-		// 调用 DeepSeek API 解释命令
-		explanation, err := client.ExplainCommand(ctx, naturalCommand)
-		if err != nil {
-			fmt.Printf("Error explaining command: %v\n", err)
-			os.Exit(1)
-		}
+	cfg, err := config.LoadConfig(a.opts.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %v", err)
+	}
+	cfg.KubeContext = a.opts.kubeContext
+	cfg.Namespace = a.opts.namespace
+	cfg.AssumeYes = a.opts.yes
+	cfg.DryRun = a.opts.dryRun
+	a.cfg = cfg
+	if a.opts.model != "" {
+		a.setModel(a.opts.model)
+	}
+	return nil
+}
 
-		// 打印解释
-		fmt.Println(explanation)
-	case "exec":
-		// 进入交互模式
-		fmt.Println("进入交互模式，输入 'exit' 退出")
-		scanner := bufio.NewScanner(os.Stdin)
-		for {
-			fmt.Print("\n请输入问题:")
-			var input string
-			if scanner.Scan() {
-				input = scanner.Text()
-			}
+// setModel 让所有用途都使用指定的模型，之后创建的大模型客户端生效
+func (a *app) setModel(model string) {
+	a.cfg.Model.Model = model
+	for name, profile := range a.cfg.Profiles {
+		profile.Model = model
+		a.cfg.Profiles[name] = profile
+	}
+}
 
-			if input == "exit" {
-				fmt.Println("退出交互模式")
-				break
-			}
+// runtime 是调用大模型并执行命令所需的对象
+type runtime struct {
+	client   llm.Provider
+	executor *kubectl.Executor
+	runner   *agent.Agent
+	tracker  *usage.Tracker
+	// audit 是审计日志，未开启审计时为空
+	audit *audit.Logger
+}
 
-			// 调用 DeepSeek API 转换命令
-			kubectlCommand, err := client.TranslateCommand(ctx, input)
-			if err != nil {
-				fmt.Printf("Error translating command: %v\n", err)
-				continue
-			}
+// newRuntime 根据配置创建大模型客户端、kubectl 执行器和先收集信息再执行的 Agent
+func (a *app) newRuntime() (*runtime, error) {
+	executor, err := a.newExecutor()
+	if err != nil {
+		return nil, err
+	}
+	rt := &runtime{
+		executor: executor,
+		tracker:  usage.NewTracker(a.cfg.DataDir, a.cfg.ModelPrices, a.cfg.MonthlySpendCap),
+	}
 
-			// 执行命令并获取输出
-			output, err := executor.ExecuteNaturalCommand(ctx, kubectlCommand)
-			if err != nil {
-				fmt.Printf("Error executing command: %v\n", err)
-				continue
-			}
+	// 记录模型调用与命令执行的审计日志
+	if a.cfg.AuditEnabled {
+		if rt.audit, err = a.newAuditLogger(); err != nil {
+			return nil, err
+		}
+		executor.SetAudit(rt.audit)
+	}
 
-			// 如果有输出，直接打印
-			if output != "" {
-				fmt.Printf("\n%s\n", output)
-			}
+	if err := a.connect(rt); err != nil {
+		return nil, err
+	}
+	return rt, nil
+}
 
-			// 询问用户是否继续
-			for {
-				fmt.Print("\n是否基于当前结果继续对话？(y/n): ")
-				var response string
-				if scanner.Scan() {
-					response = scanner.Text()
-				}
-				response = strings.ToLower(strings.TrimSpace(response))
-				if response == "y" || response == "n" {
-					if response == "y" {
-						// 将当前输出作为上下文
-						fmt.Print("\n请输入新的问题: ")
-						if scanner.Scan() {
-							input = scanner.Text()
-							// 将新问题和上下文一起提交给 AI
-							contextCommand := fmt.Sprintf("基于上次执行结果：%s\n新的问题：%s", output, input)
-							kubectlCommand, err = client.TranslateCommand(ctx, contextCommand)
-							if err != nil {
-								fmt.Printf("Error translating command with context: %v\n", err)
-								break
-							}
-
-							// 执行新的命令
-							output, err = executor.ExecuteNaturalCommand(ctx, kubectlCommand)
-							if err != nil {
-								fmt.Printf("Error executing command: %v\n", err)
-							}
-							// 如果有输出，直接打印
-							if output != "" {
-								fmt.Printf("\n%s\n", output)
-							}
-						}
-					}
-					break
-				}
-				fmt.Println("请输入 y 或 n")
-			}
-		}
+// connect 按当前配置创建大模型客户端和 Agent，切换模型后重新调用，用量统计和审计日志保持不变
+func (a *app) connect(rt *runtime) error {
+	client, err := provider.New(a.cfg, rt.tracker)
+	if err != nil {
+		return fmt.Errorf("failed to create llm provider: %v", err)
+	}
+	if rt.audit != nil {
+		client = audit.WrapProvider(client, rt.audit)
+	}
+	rt.client = client
+	rt.runner = agent.New(client, rt.executor, a.cfg)
+	return nil
+}
 
-	default:
-		fmt.Printf("Unknown subcommand: %s\n", subCommand)
-		fmt.Println("Usage: kubectl ai <cmd|explain|exec> \"<natural language command>\"")
-		os.Exit(1)
+// worker 为并发执行的请求创建独立的执行器和 Agent，大模型客户端、用量统计和审计日志与 rt 共用
+func (a *app) worker(rt *runtime) (*runtime, error) {
+	executor, err := a.newExecutor()
+	if err != nil {
+		return nil, err
+	}
+	if rt.audit != nil {
+		executor.SetAudit(rt.audit)
+	}
+	return &runtime{
+		client:   rt.client,
+		executor: executor,
+		runner:   agent.New(rt.client, executor, a.cfg),
+		tracker:  rt.tracker,
+		audit:    rt.audit,
+	}, nil
+}
+
+// newExecutor 创建 kubectl 执行器
+func (a *app) newExecutor() (*kubectl.Executor, error) {
+	executor, err := kubectl.NewExecutor(a.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create executor: %v", err)
+	}
+	executor.SetOutput(a.out)
+	return executor, nil
+}
+
+// newAuditLogger 打开数据目录下的审计日志
+func (a *app) newAuditLogger() (*audit.Logger, error) {
+	logger, err := audit.NewLogger(filepath.Join(a.cfg.DataDir, "audit"), a.cfg.AuditMaxSize, a.cfg.AuditMaxFiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	return logger, nil
+}
+
+// newConversation 按配置创建多轮对话，未开启多轮对话时返回空
+func (a *app) newConversation() *llm.Conversation {
+	if !a.cfg.EnableChat {
+		return nil
 	}
+	conv := llm.NewConversation(llm.DefaultMaxMessages)
+	conv.SetSummaryThreshold(a.cfg.ChatSummaryTokens)
+	return conv
 }
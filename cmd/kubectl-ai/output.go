@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yourusername/kubectl-ai/pkg/agent"
+	"github.com/yourusername/kubectl-ai/pkg/kubectl"
+	"github.com/yourusername/kubectl-ai/pkg/llm"
+	"github.com/yourusername/kubectl-ai/pkg/usage"
+)
+
+// --output 支持的输出格式
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// structuredAnnotation 标记支持 --output json|yaml 的子命令
+const structuredAnnotation = "kubectl-ai/structured-output"
+
+// 进程退出码，便于脚本区分失败的原因
+const (
+	exitFailure     = 1 // 参数、配置等其他错误
+	exitTranslation = 2 // 模型未能把输入转换为可执行的命令
+	exitCancelled   = 3 // 用户在确认提示中取消了执行
+	exitDenied      = 4 // 命令被策略规则拒绝
+	exitKubectl     = 5 // kubectl 命令执行失败
+)
+
+// exitCode 返回错误对应的进程退出码
+func exitCode(err error) int {
+	var cmdErr *kubectl.CommandError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, kubectl.ErrCancelled), errors.Is(err, context.Canceled):
+		return exitCancelled
+	case errors.Is(err, kubectl.ErrPolicyDenied):
+		return exitDenied
+	case errors.As(err, &cmdErr):
+		return exitKubectl
+	case errors.Is(err, agent.ErrTranslation):
+		return exitTranslation
+	default:
+		return exitFailure
+	}
+}
+
+// report 是一次自然语言输入的结构化结果：执行的每条命令及其风险等级、输出和退出码，以及本次输入的 token 用量
+type report struct {
+	// Line 是 batch 子命令中请求在文件中的行号
+	Line     int              `json:"line,omitempty" yaml:"line,omitempty"`
+	Input    string           `json:"input" yaml:"input"`
+	Commands []kubectl.Result `json:"commands" yaml:"commands"`
+	Output   string           `json:"output" yaml:"output"`
+	Usage    usage.Totals     `json:"usage" yaml:"usage"`
+	Error    string           `json:"error,omitempty" yaml:"error,omitempty"`
+	ExitCode int              `json:"exit_code" yaml:"exit_code"`
+}
+
+// explanation 是 explain 子命令的结构化结果
+type explanation struct {
+	Input       string       `json:"input" yaml:"input"`
+	Explanation string       `json:"explanation" yaml:"explanation"`
+	Usage       usage.Totals `json:"usage" yaml:"usage"`
+	Error       string       `json:"error,omitempty" yaml:"error,omitempty"`
+	ExitCode    int          `json:"exit_code" yaml:"exit_code"`
+}
+
+// run 执行一次自然语言输入，记录执行的每条命令及其结果和本次输入的 token 用量
+func (rt *runtime) run(ctx context.Context, conv *llm.Conversation, input string) (*report, error) {
+	rep := &report{Input: input, Commands: []kubectl.Result{}}
+	rt.executor.SetObserver(func(result kubectl.Result) {
+		rep.Commands = append(rep.Commands, result)
+	})
+	defer rt.executor.SetObserver(nil)
+
+	ctx, counter := usage.WithCounter(ctx)
+	output, err := rt.runner.Run(ctx, conv, input)
+	rep.Output = output
+	rep.Usage = counter.Totals()
+	if err != nil {
+		err = fmt.Errorf("failed to execute command: %w", err)
+		rep.Error, rep.ExitCode = err.Error(), exitCode(err)
+	}
+	return rep, err
+}
+
+// structured 判断是否使用 json 或 yaml 输出
+func (a *app) structured() bool {
+	return a.opts.output != outputText
+}
+
+// emit 按 --output 指定的格式把结构化结果写到标准输出
+func (a *app) emit(v interface{}) error {
+	var buf bytes.Buffer
+	var err error
+	if a.opts.output == outputYAML {
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		err = enc.Encode(v)
+	} else {
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(v)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %v", err)
+	}
+	if _, err := a.stdout.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write output: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yourusername/kubectl-ai/pkg/kubectl"
+)
+
+// newPolicyCommand 创建 policy 子命令：测试命令的风险等级与匹配的策略规则
+func newPolicyCommand(a *app) *cobra.Command {
+	policy := &cobra.Command{
+		Use:   "policy",
+		Short: "测试命令策略",
+	}
+
+	test := &cobra.Command{
+		Use:   "test <kubectl 命令>",
+		Short: "显示命令的风险等级、目标和匹配的策略规则，不执行命令",
+		Example: `  kubectl ai policy test "kubectl delete pod web -n prod"
+  kubectl ai policy test -- kubectl delete pod web -n prod`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			executor, err := a.newExecutor()
+			if err != nil {
+				return err
+			}
+
+			// 以 -- 分隔传入的参数保留原有的分词，重新引用后与实际执行时的解析结果一致
+			command := args[0]
+			if len(args) > 1 {
+				command = kubectl.QuoteArgv(args)
+			}
+			assessment, target, decision, err := executor.CheckCommand(cmd.Context(), command)
+			if err != nil {
+				return fmt.Errorf("failed to parse command: %v", err)
+			}
+
+			fmt.Printf("命令: %s\n", command)
+			fmt.Printf("风险等级: %s%s\n", assessment.Level.Label(), assessment.Level)
+			for _, reason := range assessment.Reasons {
+				fmt.Printf("  - %s\n", reason)
+			}
+			fmt.Printf("Context: %s\n", target.Context)
+			fmt.Printf("命名空间: %s\n", target.Namespace)
+			fmt.Printf("策略: %s\n", decision)
+			return nil
+		},
+	}
+
+	policy.AddCommand(test)
+	return policy
+}
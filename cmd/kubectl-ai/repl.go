@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/chzyer/readline"
+
+	"github.com/yourusername/kubectl-ai/pkg/config"
+	"github.com/yourusername/kubectl-ai/pkg/kubectl"
+	"github.com/yourusername/kubectl-ai/pkg/llm"
+	"github.com/yourusername/kubectl-ai/pkg/session"
+	"github.com/yourusername/kubectl-ai/pkg/utils"
+)
+
+// replCommand 是交互模式中的一条斜杠命令
+type replCommand struct {
+	name  string
+	args  string
+	usage string
+}
+
+// replCommands 是交互模式支持的斜杠命令，按 /help 中的显示顺序排列
+var replCommands = []replCommand{
+	{"/ns", "[namespace]", "查看或切换命令默认作用的命名空间"},
+	{"/context", "[context]", "查看或切换命令默认使用的 kube-context"},
+	{"/model", "[model]", "查看或切换使用的模型"},
+	{"/history", "", "列出写操作的撤销记录"},
+	{"/undo", "[id]", "恢复写操作执行前的快照，未指定 id 时恢复最近一条"},
+	{"/reset", "", "清空对话历史并开始新的会话"},
+	{"/save", "[file]", "立即保存会话，指定文件时同时导出为 JSON"},
+	{"/explain-last", "", "解释最近执行的一条命令"},
+	{"/continue", "<question>", "基于上一次的输出继续提问"},
+	{"/help", "", "显示斜杠命令"},
+	{"/exit", "", "退出交互模式，也可以按 Ctrl-D"},
+}
+
+// repl 是交互模式的状态：行编辑器、当前会话及其对话
+type repl struct {
+	app   *app
+	rt    *runtime
+	rl    *readline.Instance
+	store *session.Store
+	sess  *session.Session
+	conv  *llm.Conversation
+	// inputPrompt 是显示当前 context 与命名空间的输入提示
+	inputPrompt string
+	// lastOutput 是上一次输入的最终输出，用于 /continue
+	lastOutput string
+}
+
+// runInteractive 进入交互模式：支持行编辑、持久化的输入历史和斜杠命令，每次输入执行后保存会话。
+// 执行过程中按 Ctrl-C 只取消正在进行的模型调用或命令，不会退出交互模式
+func (a *app) runInteractive(ctx context.Context, rt *runtime, sess *session.Session) error {
+	r := &repl{app: a, rt: rt, store: session.NewStore(a.cfg.DataDir)}
+	r.start(sess)
+	r.updatePrompt(ctx)
+
+	historyFile, err := a.historyFile()
+	if err != nil {
+		return err
+	}
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:            r.inputPrompt,
+		HistoryFile:       historyFile,
+		HistorySearchFold: true,
+		AutoComplete:      r.completer(),
+		InterruptPrompt:   "^C",
+		EOFPrompt:         "/exit",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start line editor: %v", err)
+	}
+	defer rl.Close()
+	r.rl = rl
+	kubectl.SetLineReader(r.readLine)
+
+	fmt.Printf("进入交互模式（会话 %s），输入 /help 查看命令，/exit 或 Ctrl-D 退出\n", r.sess.ID)
+	for {
+		line, err := rl.Readline()
+		if errors.Is(err, readline.ErrInterrupt) {
+			if line == "" {
+				fmt.Println("输入 /exit 或按 Ctrl-D 退出")
+			}
+			continue
+		}
+		if err != nil {
+			break
+		}
+
+		input := strings.TrimSpace(line)
+		if input == "exit" || input == "/exit" {
+			break
+		}
+		if strings.HasPrefix(input, "/") {
+			r.command(ctx, input)
+		} else if input != "" {
+			r.ask(ctx, input)
+		}
+	}
+
+	fmt.Println("退出交互模式")
+	if len(r.sess.Turns) > 0 {
+		fmt.Printf("会话已保存，可使用 kubectl ai sessions resume %s 继续\n", r.sess.ID)
+	}
+	fmt.Print(rt.tracker.Summary())
+	return nil
+}
+
+// historyFile 返回交互模式输入历史的文件路径，文件不存在时以仅当前用户可读写的权限创建
+func (a *app) historyFile() (string, error) {
+	if err := os.MkdirAll(a.cfg.DataDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create data directory: %v", err)
+	}
+	path := filepath.Join(a.cfg.DataDir, "exec_history")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to open input history: %v", err)
+	}
+	file.Close()
+	return path, nil
+}
+
+// start 开始或恢复会话，开启多轮对话时恢复会话保存的对话历史
+func (r *repl) start(sess *session.Session) {
+	r.sess = sess
+	r.lastOutput = ""
+	if r.conv = r.app.newConversation(); r.conv != nil {
+		sess.Attach(r.conv)
+	}
+}
+
+// ask 在会话中执行一次输入，记录执行的命令和输出并保存会话
+func (r *repl) ask(ctx context.Context, input string) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	// 转换命令，收集必要信息后执行并获取输出
+	turn := r.sess.Begin(input)
+	r.rt.runner.SetObserver(turn.Record)
+	output, err := r.rt.runner.Run(ctx, r.conv, input)
+	turn.Finish(output, err)
+	r.save()
+
+	switch {
+	case ctx.Err() != nil:
+		fmt.Println("\n已取消")
+	case err != nil:
+		fmt.Printf("Error executing command: %v\n", err)
+	default:
+		r.lastOutput = output
+		if output != "" {
+			fmt.Printf("\n%s\n", output)
+		}
+	}
+}
+
+// save 保存会话，失败时只记录警告
+func (r *repl) save() {
+	if err := r.store.Save(r.sess); err != nil {
+		config.Logger.WithError(err).Warn("Failed to save session")
+	}
+}
+
+// command 执行一条斜杠命令
+func (r *repl) command(ctx context.Context, input string) {
+	name, rest, _ := strings.Cut(input, " ")
+	rest = strings.TrimSpace(rest)
+	args := strings.Fields(rest)
+
+	var err error
+	switch name {
+	case "/ns":
+		scope := r.rt.executor.Scope()
+		if len(args) > 0 {
+			scope.Namespace = args[0]
+		}
+		r.setScope(ctx, scope)
+	case "/context":
+		scope := r.rt.executor.Scope()
+		if len(args) > 0 {
+			if known := r.app.contexts(); len(known) > 0 && !slices.Contains(known, args[0]) {
+				err = fmt.Errorf("context %s not found in kubeconfig", args[0])
+				break
+			}
+			scope.Context = args[0]
+		}
+		r.setScope(ctx, scope)
+	case "/model":
+		if len(args) > 0 {
+			r.app.setModel(args[0])
+			err = r.app.connect(r.rt)
+		}
+		if err == nil {
+			fmt.Printf("当前模型: %s\n", r.app.cfg.Profiles[config.ProfileTranslate].Model)
+		}
+	case "/history":
+		err = printJournal(kubectl.NewJournal(r.app.cfg.DataDir))
+	case "/undo":
+		id := 0
+		if len(args) > 0 {
+			if id, err = parseJournalID(args[0]); err != nil {
+				break
+			}
+		}
+		err = r.cancelable(ctx, func(ctx context.Context) error { return r.rt.executor.Undo(ctx, id) })
+	case "/reset":
+		r.start(session.New())
+		fmt.Printf("已清空对话历史，开始新的会话 %s\n", r.sess.ID)
+	case "/save":
+		if err = r.store.Save(r.sess); err != nil {
+			break
+		}
+		fmt.Printf("会话已保存，可使用 kubectl ai sessions resume %s 继续\n", r.sess.ID)
+		if len(args) > 0 {
+			if err = r.sess.WriteFile(args[0]); err == nil {
+				fmt.Printf("已导出到 %s\n", args[0])
+			}
+		}
+	case "/explain-last":
+		err = r.explainLast(ctx)
+	case "/continue":
+		if rest == "" || r.lastOutput == "" {
+			fmt.Println("用法: /continue <question>，需要先有一次成功执行的输出")
+			break
+		}
+		r.ask(ctx, fmt.Sprintf("基于上次执行结果：%s\n新的问题：%s", r.rt.runner.Fit(r.sess.LastCommand(), r.lastOutput), rest))
+	case "/help":
+		for _, c := range replCommands {
+			fmt.Printf("  %-24s %s\n", strings.TrimSpace(c.name+" "+c.args), c.usage)
+		}
+	default:
+		fmt.Printf("未知命令 %s，输入 /help 查看支持的命令\n", name)
+	}
+	if err != nil {
+		fmt.Printf("%s%v\n", utils.Red("[错误] "), err)
+	}
+}
+
+// setScope 切换命令默认作用的目标，并显示切换后的 context 与命名空间
+func (r *repl) setScope(ctx context.Context, scope kubectl.Scope) {
+	r.rt.executor.SetScope(scope)
+	target := r.rt.executor.DefaultTarget(ctx)
+	fmt.Printf("Context: %s\n命名空间: %s\n", target.Context, target.Namespace)
+	r.updatePrompt(ctx)
+}
+
+// explainLast 解释会话中最近执行的一条命令
+func (r *repl) explainLast(ctx context.Context) error {
+	last := r.sess.LastCommand()
+	if last == "" {
+		fmt.Println("还没有执行过命令")
+		return nil
+	}
+	return r.cancelable(ctx, func(ctx context.Context) error {
+		fmt.Printf("$ %s\n", last)
+		explanation, err := r.rt.client.ExplainCommand(ctx, last, func(content string) {
+			fmt.Print(content)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to explain command: %v", err)
+		}
+		fmt.Println(explanation)
+		return nil
+	})
+}
+
+// cancelable 执行 fn，期间按 Ctrl-C 取消 fn 使用的上下文而不退出交互模式
+func (r *repl) cancelable(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	err := fn(ctx)
+	if ctx.Err() != nil {
+		fmt.Println("\n已取消")
+		return nil
+	}
+	return err
+}
+
+// updatePrompt 更新输入提示中显示的 context 与命名空间
+func (r *repl) updatePrompt(ctx context.Context) {
+	target := r.rt.executor.DefaultTarget(ctx)
+	r.inputPrompt = utils.Blue(fmt.Sprintf("[%s/%s]", target.Context, target.Namespace)) + " 请输入问题: "
+	if r.rl != nil {
+		r.rl.SetPrompt(r.inputPrompt)
+	}
+}
+
+// readLine 供执行器读取确认输入，使用行编辑器读取且不计入输入历史；按 Ctrl-C 视为拒绝
+func (r *repl) readLine(prompt string) string {
+	r.rl.HistoryDisable()
+	defer r.rl.HistoryEnable()
+	r.rl.SetPrompt(prompt)
+	defer r.rl.SetPrompt(r.inputPrompt)
+
+	line, err := r.rl.Readline()
+	if err != nil {
+		return ""
+	}
+	return line
+}
+
+// completer 返回斜杠命令的 Tab 补全，/ns 与 /context 补全集群中的命名空间和 kubeconfig 中的 context
+func (r *repl) completer() readline.AutoCompleter {
+	items := make([]readline.PrefixCompleterInterface, 0, len(replCommands))
+	for _, c := range replCommands {
+		switch c.name {
+		case "/ns":
+			items = append(items, readline.PcItem(c.name, readline.PcItemDynamic(func(string) []string {
+				return r.app.namespaces(r.rt.executor.Scope().Context)
+			})))
+		case "/context":
+			items = append(items, readline.PcItem(c.name, readline.PcItemDynamic(func(string) []string {
+				return r.app.contexts()
+			})))
+		default:
+			items = append(items, readline.PcItem(c.name))
+		}
+	}
+	return readline.NewPrefixCompleter(items...)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yourusername/kubectl-ai/pkg/session"
+	"github.com/yourusername/kubectl-ai/pkg/utils"
+)
+
+// newSessionsCommand 创建 sessions 子命令：管理保存在本地的交互式会话
+func newSessionsCommand(a *app) *cobra.Command {
+	sessions := &cobra.Command{
+		Use:   "sessions",
+		Short: "列出、查看、恢复或删除保存的交互式会话",
+		Long:  "交互模式的每次输入、执行的命令及其输出以及对话历史保存在数据目录的 sessions 目录中。<id> 可以使用能唯一确定会话的前缀。",
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "按最近更新时间列出会话",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, err := session.NewStore(a.cfg.DataDir).List()
+			if err != nil {
+				return fmt.Errorf("failed to read sessions: %v", err)
+			}
+			if len(all) == 0 {
+				fmt.Println("没有保存的会话")
+				return nil
+			}
+			for _, sess := range all {
+				fmt.Printf("%s  %s  %3d 轮  %s\n", sess.ID, sess.Updated.Format("2006-01-02 15:04:05"),
+					len(sess.Turns), sess.Title())
+			}
+			return nil
+		},
+	}
+
+	show := &cobra.Command{
+		Use:               "show <id>",
+		Short:             "查看会话中执行的命令和输出",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeSessions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sess, err := session.NewStore(a.cfg.DataDir).Load(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to load session: %v", err)
+			}
+			printSession(sess)
+			return nil
+		},
+	}
+
+	resume := &cobra.Command{
+		Use:               "resume <id>",
+		Short:             "恢复会话的对话历史并继续交互",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeSessions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sess, err := session.NewStore(a.cfg.DataDir).Load(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to load session: %v", err)
+			}
+			rt, err := a.newRuntime()
+			if err != nil {
+				return err
+			}
+			printSession(sess)
+			return a.runInteractive(cmd.Context(), rt, sess)
+		},
+	}
+
+	remove := &cobra.Command{
+		Use:               "delete <id>",
+		Short:             "删除会话",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: a.completeSessions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := session.NewStore(a.cfg.DataDir).Delete(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to delete session: %v", err)
+			}
+			fmt.Printf("已删除会话 %s\n", id)
+			return nil
+		},
+	}
+
+	sessions.AddCommand(list, show, resume, remove)
+	return sessions
+}
+
+// printSession 显示会话中每次输入执行的命令和输出
+func printSession(sess *session.Session) {
+	fmt.Printf("会话 %s，创建于 %s，更新于 %s\n", sess.ID,
+		sess.Created.Format("2006-01-02 15:04:05"), sess.Updated.Format("2006-01-02 15:04:05"))
+	for i, turn := range sess.Turns {
+		fmt.Printf("\n%s#%d %s %s\n", utils.Blue("[输入] "), i+1, turn.Time.Format("15:04:05"), turn.Input)
+		for _, exec := range turn.Executions {
+			fmt.Printf("$ %s\n", exec.Command)
+			if exec.Output != "" {
+				fmt.Println(strings.TrimRight(exec.Output, "\n"))
+			}
+			if exec.Error != "" {
+				fmt.Printf("%s%s\n", utils.Red("[错误] "), exec.Error)
+			}
+		}
+		if turn.Error != "" {
+			fmt.Printf("%s%s\n", utils.Red("[错误] "), turn.Error)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/spf13/cobra"
+)
+
+// 构建信息，发布时通过 -ldflags "-X main.version=... -X main.commit=... -X main.date=..." 注入
+var (
+	version = "dev"
+	commit  = ""
+	date    = ""
+)
+
+// buildInfo 返回版本、提交和构建时间，未注入时使用 Go 记录的版本控制信息
+func buildInfo() (ver, rev, built, goVersion, platform string) {
+	ver, rev, built = version, commit, date
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ver, rev, built, "", ""
+	}
+	if ver == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		ver = info.Main.Version
+	}
+	var goos, goarch string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if rev == "" {
+				rev = s.Value
+			}
+		case "vcs.time":
+			if built == "" {
+				built = s.Value
+			}
+		case "GOOS":
+			goos = s.Value
+		case "GOARCH":
+			goarch = s.Value
+		}
+	}
+	if goos != "" {
+		platform = goos + "/" + goarch
+	}
+	return ver, rev, built, info.GoVersion, platform
+}
+
+// newVersionCommand 创建 version 子命令：显示构建信息，不需要加载配置
+func newVersionCommand(a *app) *cobra.Command {
+	return skipConfig(a, &cobra.Command{
+		Use:   "version",
+		Short: "显示版本和构建信息",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ver, rev, built, goVersion, platform := buildInfo()
+			fmt.Printf("kubectl-ai %s\n", ver)
+			if rev != "" {
+				fmt.Printf("提交: %s\n", rev)
+			}
+			if built != "" {
+				fmt.Printf("构建时间: %s\n", built)
+			}
+			if goVersion != "" {
+				fmt.Printf("Go 版本: %s\n", goVersion)
+			}
+			if platform != "" {
+				fmt.Printf("平台: %s\n", platform)
+			}
+		},
+	})
+}
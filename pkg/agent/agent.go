@@ -0,0 +1,231 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/kubectl-ai/pkg/audit"
+	"github.com/yourusername/kubectl-ai/pkg/budget"
+	"github.com/yourusername/kubectl-ai/pkg/config"
+	"github.com/yourusername/kubectl-ai/pkg/kubectl"
+	"github.com/yourusername/kubectl-ai/pkg/llm"
+	"github.com/yourusername/kubectl-ai/pkg/redact"
+	"github.com/yourusername/kubectl-ai/pkg/utils"
+)
+
+// ErrTranslation 表示模型未能把输入转换为可执行的命令：调用模型失败、回复无法解析或收集信息后仍未给出最终命令
+var ErrTranslation = errors.New("failed to translate command")
+
+// Agent 实现“先收集信息、再执行”的多轮循环：
+// 执行模型返回的 [INFO] 命令，把输出反馈给模型，直到模型给出最终命令或达到步数上限
+type Agent struct {
+	provider   llm.Provider
+	executor   *kubectl.Executor
+	maxSteps   int
+	budget     *budget.Budgeter
+	maxRepairs int
+	// assumeYes 为 true 时自动确认使用修正后的命令重试
+	assumeYes bool
+	// redact 为 true 时不把会暴露 Secret 内容的命令输出发给模型
+	redact bool
+	// observer 在每条命令执行后以命令、输出和错误调用，用于记录会话
+	observer func(command, output string, err error)
+}
+
+// New 创建新的 Agent
+func New(provider llm.Provider, executor *kubectl.Executor, cfg *config.Config) *Agent {
+	return &Agent{
+		provider:   provider,
+		executor:   executor,
+		maxSteps:   cfg.AgentMaxSteps,
+		budget:     budget.New(cfg.OutputTokenBudget),
+		maxRepairs: cfg.RepairMaxAttempts,
+		assumeYes:  cfg.AssumeYes,
+		redact:     cfg.RedactEnabled,
+	}
+}
+
+// SetObserver 设置命令观察者，每条信息收集命令和最终命令执行后都会调用
+func (a *Agent) SetObserver(observer func(command, output string, err error)) {
+	a.observer = observer
+}
+
+// Run 将自然语言转换为 kubectl 命令并执行，返回最后一条命令的输出；
+// conv 不为空时在该对话的上下文中生成命令，为空时每次输入相互独立
+func (a *Agent) Run(ctx context.Context, conv *llm.Conversation, naturalCommand string) (string, error) {
+	// 同一条输入产生的模型调用与命令执行在审计日志中使用相同的请求 ID
+	ctx = audit.WithRequest(ctx, naturalCommand)
+	// 把命令默认作用的 context 与命名空间告知模型
+	target := a.executor.DefaultTarget(ctx)
+	ctx = llm.WithScope(ctx, llm.Scope{Context: target.Context, Namespace: target.Namespace})
+
+	response, err := a.provider.TranslateCommand(ctx, conv, naturalCommand)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrTranslation, err)
+	}
+
+	var observations []llm.Observation
+	for step := 1; ; step++ {
+		commands, err := kubectl.ParseCommands(response)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrTranslation, err)
+		}
+
+		info, actions := splitCommands(commands)
+		if len(info) == 0 {
+			return a.execute(ctx, naturalCommand, actions)
+		}
+
+		// 达到步数上限后不再收集信息，只执行已有的最终命令
+		if step > a.maxSteps {
+			config.Logger.WithFields(map[string]interface{}{
+				"max_steps": a.maxSteps,
+			}).Debug("Agent step budget exhausted")
+			if len(actions) == 0 {
+				return "", fmt.Errorf("%w: 已达到最大信息收集轮数 %d，模型仍未给出最终命令", ErrTranslation, a.maxSteps)
+			}
+			return a.execute(ctx, naturalCommand, actions)
+		}
+
+		output, err := a.gather(ctx, info)
+		if err != nil {
+			return "", err
+		}
+		observations = append(observations, llm.Observation{
+			Response: response,
+			Output:   output,
+		})
+
+		config.Logger.WithFields(map[string]interface{}{
+			"step":          step,
+			"info_commands": len(info),
+			"output_length": len(output),
+		}).Debug("Feeding gathered information back to LLM provider")
+
+		response, err = a.provider.RefineCommand(ctx, conv, naturalCommand, observations)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrTranslation, err)
+		}
+	}
+}
+
+// execute 依次执行最终命令，命令失败时尝试让模型修正后重试
+func (a *Agent) execute(ctx context.Context, naturalCommand string, commands []kubectl.Command) (string, error) {
+	var lastOutput string
+	for _, cmd := range commands {
+		output, err := a.run(ctx, cmd)
+		if err != nil {
+			output, err = a.repair(ctx, naturalCommand, cmd, err)
+			if err != nil {
+				return "", err
+			}
+		}
+		lastOutput = output
+	}
+	return lastOutput, nil
+}
+
+// repair 把失败的命令和 kubectl 的错误输出发给模型，展示修正前后的差异，
+// 经用户确认后重试，最多尝试 maxRepairs 次
+func (a *Agent) repair(ctx context.Context, naturalCommand string, cmd kubectl.Command, runErr error) (string, error) {
+	for attempt := 1; attempt <= a.maxRepairs; attempt++ {
+		var cmdErr *kubectl.CommandError
+		if !errors.As(runErr, &cmdErr) {
+			// 用户取消或上下文取消等非 kubectl 错误不进行修正
+			return "", runErr
+		}
+
+		fmt.Fprintf(a.executor.Output(), "\n%s命令执行失败，正在尝试修正（第 %d/%d 次）...\n", utils.Yellow("[修正] "), attempt, a.maxRepairs)
+		response, err := a.provider.RepairCommand(ctx, naturalCommand, cmd.Cmd, a.fit(cmdErr.Output, 0))
+		if err != nil {
+			return "", fmt.Errorf("%w: failed to repair command: %w", ErrTranslation, err)
+		}
+		commands, err := kubectl.ParseCommands(response)
+		if err != nil {
+			return "", runErr
+		}
+		// 修正只替换失败的这一条命令，多条命令无法与原命令逐条对应，也不能只执行其中一条
+		if len(commands) != 1 {
+			return "", fmt.Errorf("%w: 修正结果包含 %d 条命令，只能是一条: %w", ErrTranslation, len(commands), runErr)
+		}
+		fixed := commands[0]
+		if fixed.Cmd == cmd.Cmd {
+			return "", fmt.Errorf("模型未能给出不同的修正命令: %w", runErr)
+		}
+
+		fmt.Fprintf(a.executor.Output(), "\n%s\n", utils.DiffCommand(cmd.Cmd, fixed.Cmd))
+		if !a.assumeYes && !a.executor.Confirm("是否使用修正后的命令重试？") {
+			return "", runErr
+		}
+
+		// 原命令被标记为危险时，修正后的命令同样按危险命令处理
+		if cmd.Type == kubectl.CommandDangerous {
+			fixed.Type = kubectl.CommandDangerous
+		}
+		output, err := a.run(ctx, fixed)
+		if err == nil {
+			return output, nil
+		}
+		cmd, runErr = fixed, err
+	}
+	return "", runErr
+}
+
+// gather 执行 [INFO] 命令，返回截断后的合并输出；单条命令失败时把错误作为输出反馈给模型
+func (a *Agent) gather(ctx context.Context, info []kubectl.Command) (string, error) {
+	var sb strings.Builder
+	for _, cmd := range info {
+		output, err := a.run(ctx, cmd)
+		if err != nil {
+			if errors.Is(err, kubectl.ErrCancelled) || ctx.Err() != nil {
+				return "", err
+			}
+			output = err.Error()
+		}
+		fmt.Fprintf(&sb, "$ %s\n%s\n", cmd.Cmd, a.fit(a.sanitize(cmd.Cmd, output), a.budget.MaxTokens()/len(info)))
+	}
+	return sb.String(), nil
+}
+
+// run 执行一条命令并通知观察者
+func (a *Agent) run(ctx context.Context, cmd kubectl.Command) (string, error) {
+	output, err := a.executor.Run(ctx, cmd)
+	if a.observer != nil {
+		a.observer(cmd.Cmd, output, err)
+	}
+	return output, err
+}
+
+// splitCommands 将命令分为信息收集命令和最终执行的命令
+func splitCommands(commands []kubectl.Command) (info, actions []kubectl.Command) {
+	for _, cmd := range commands {
+		if cmd.Type == kubectl.CommandInfo {
+			info = append(info, cmd)
+		} else {
+			actions = append(actions, cmd)
+		}
+	}
+	return info, actions
+}
+
+// fit 将命令输出裁剪到 token 预算以内，limit 不大于 0 时使用完整预算
+func (a *Agent) fit(output string, limit int) string {
+	output, _ = a.budget.Fit(output, limit)
+	return output
+}
+
+// Fit 将命令输出脱敏并裁剪到 token 预算以内，用于把上一次的执行结果带入后续对话
+func (a *Agent) Fit(command, output string) string {
+	return a.fit(a.sanitize(command, output), 0)
+}
+
+// sanitize 在开启脱敏时屏蔽会暴露 Secret 内容的命令的整段输出，
+// 如 -o jsonpath='{.data.password}' 的输出只是一段简短的 base64，无法从文本中识别
+func (a *Agent) sanitize(command, output string) string {
+	if a.redact && kubectl.ExposesSecretData(command) {
+		return redact.Mask
+	}
+	return output
+}
@@ -0,0 +1,247 @@
+package anthropic
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/yourusername/kubectl-ai/pkg/config"
+	"github.com/yourusername/kubectl-ai/pkg/llm"
+)
+
+const (
+	// DefaultEndpoint 是 Anthropic Messages API 端点
+	DefaultEndpoint = "https://api.anthropic.com/v1/messages"
+	// DefaultModel 是 Anthropic 默认模型
+	DefaultModel = "claude-3-5-sonnet-latest"
+
+	// apiVersion 是请求头 anthropic-version 的取值
+	apiVersion = "2023-06-01"
+	// defaultMaxTokens 是 Messages API 必填的 max_tokens 默认值
+	defaultMaxTokens = 4096
+)
+
+// Client 代表 Anthropic 风格 Messages API 的客户端
+type Client struct {
+	apiKey     string
+	endpoint   string
+	model      string
+	opts       llm.Options
+	httpClient *llm.HTTPClient
+}
+
+// NewClient 创建新的 Anthropic 客户端，Messages API 不支持 seed 和 JSON 模式，设置后忽略，
+// 结构化输出依赖提示词约束
+func NewClient(opts llm.Options) *Client {
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	model := opts.Model
+	if model == "" {
+		model = DefaultModel
+	}
+	return &Client{
+		apiKey:     opts.APIKey,
+		endpoint:   endpoint,
+		model:      model,
+		opts:       opts,
+		httpClient: llm.NewHTTPClient(opts),
+	}
+}
+
+// Chat 发送非流式请求
+func (c *Client) Chat(ctx context.Context, messages []llm.Message) (string, error) {
+	return c.sendMessagesRequest(ctx, messages, false, nil)
+}
+
+// Stream 发送流式请求
+func (c *Client) Stream(ctx context.Context, messages []llm.Message, onDelta func(string)) (string, error) {
+	return c.sendMessagesRequest(ctx, messages, true, onDelta)
+}
+
+// buildRequest 将通用消息转换为 Messages API 请求：
+// system 消息单独放入 system 字段，相邻同角色的消息合并
+func (c *Client) buildRequest(messages []llm.Message, stream bool) MessagesRequest {
+	request := MessagesRequest{
+		Model:       c.model,
+		MaxTokens:   defaultMaxTokens,
+		Stream:      stream,
+		Temperature: c.opts.Temperature,
+		TopP:        c.opts.TopP,
+	}
+	if c.opts.MaxTokens > 0 {
+		request.MaxTokens = c.opts.MaxTokens
+	}
+
+	var system []string
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			system = append(system, msg.Content)
+			continue
+		}
+		if n := len(request.Messages); n > 0 && request.Messages[n-1].Role == msg.Role {
+			request.Messages[n-1].Content += "\n\n" + msg.Content
+			continue
+		}
+		request.Messages = append(request.Messages, msg)
+	}
+	request.System = strings.Join(system, "\n\n")
+
+	return request
+}
+
+// sendMessagesRequest 发送请求到 Messages API
+func (c *Client) sendMessagesRequest(ctx context.Context, messages []llm.Message, stream bool, onDelta func(string)) (string, error) {
+	requestBody, err := json.Marshal(c.buildRequest(messages, stream))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	config.Logger.WithFields(map[string]interface{}{
+		"request_body": string(requestBody),
+		"stream":       stream,
+	}).Debug("Sending request to Anthropic API")
+
+	header := http.Header{}
+	header.Set("x-api-key", c.apiKey)
+	header.Set("anthropic-version", apiVersion)
+	resp, err := c.httpClient.Post(ctx, c.endpoint, header, requestBody, stream)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if stream {
+		var result strings.Builder
+		var usage Usage
+		reader := bufio.NewReader(resp.Body)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				if err == io.EOF {
+					break
+				}
+				return "", fmt.Errorf("%w: failed to read stream: %v", llm.ErrNetwork, err)
+			}
+
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+
+			var event StreamEvent
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+				return "", fmt.Errorf("failed to unmarshal stream response: %v", err)
+			}
+			// 输入 token 在 message_start 中返回，输出 token 在 message_delta 中累计返回
+			if event.Message != nil {
+				usage = event.Message.Usage
+			}
+			if event.Usage != nil {
+				usage.OutputTokens = event.Usage.OutputTokens
+			}
+			if event.Type == "message_stop" {
+				break
+			}
+			if event.Type != "content_block_delta" || event.Delta.Type != "text_delta" {
+				continue
+			}
+
+			if onDelta != nil {
+				onDelta(event.Delta.Text)
+			}
+			result.WriteString(event.Delta.Text)
+		}
+		c.reportUsage(ctx, usage)
+		return result.String(), nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to read response: %v", llm.ErrNetwork, err)
+	}
+	config.Logger.Debug(string(body))
+
+	var response MessagesResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %v", err)
+	}
+
+	c.reportUsage(ctx, response.Usage)
+	var text strings.Builder
+	for _, block := range response.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no response from API")
+	}
+
+	return text.String(), nil
+}
+
+// reportUsage 将响应中的 token 用量交给 OnUsage 回调，input_tokens 不包含缓存读取的部分
+func (c *Client) reportUsage(ctx context.Context, usage Usage) {
+	if c.opts.OnUsage == nil {
+		return
+	}
+	c.opts.OnUsage(ctx, c.model, llm.Usage{
+		PromptTokens:     usage.InputTokens + usage.CacheReadInputTokens + usage.CacheCreationInputTokens,
+		CompletionTokens: usage.OutputTokens,
+		CacheHitTokens:   usage.CacheReadInputTokens,
+	})
+}
+
+// MessagesRequest 表示发送到 Messages API 的请求
+type MessagesRequest struct {
+	Model       string        `json:"model"`
+	System      string        `json:"system,omitempty"`
+	Messages    []llm.Message `json:"messages"`
+	MaxTokens   int           `json:"max_tokens"`
+	Stream      bool          `json:"stream"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	TopP        *float64      `json:"top_p,omitempty"`
+}
+
+// MessagesResponse 表示 Messages API 的非流式响应
+type MessagesResponse struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Role    string `json:"role"`
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      Usage  `json:"usage"`
+}
+
+// Usage 表示响应中的 token 用量
+type Usage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+}
+
+// StreamEvent 表示 Messages API 流式响应中的单个事件
+type StreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	// Message 只出现在 message_start 事件中
+	Message *struct {
+		Usage Usage `json:"usage"`
+	} `json:"message"`
+	// Usage 只出现在 message_delta 事件中
+	Usage *Usage `json:"usage"`
+}
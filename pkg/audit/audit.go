@@ -0,0 +1,323 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 审计记录的类型
+const (
+	// TypePrompt 是一次大模型调用：自然语言输入与模型响应
+	TypePrompt = "prompt"
+	// TypeExecution 是一次命令执行：命令、风险等级、确认结果与退出状态
+	TypeExecution = "execution"
+)
+
+// 命令执行的确认结果
+const (
+	DecisionNotRequired = "not-required"
+	DecisionConfirmed   = "confirmed"
+	DecisionCancelled   = "cancelled"
+	DecisionDenied      = "denied"
+	DecisionDryRun      = "dry-run"
+)
+
+// Record 是一条审计记录，Hash 为前一条记录的 Hash 与本记录内容的 SHA-256，构成哈希链
+type Record struct {
+	Time       time.Time `json:"time"`
+	Type       string    `json:"type"`
+	RequestID  string    `json:"request_id,omitempty"`
+	User       string    `json:"user"`
+	Host       string    `json:"host"`
+	Context    string    `json:"context,omitempty"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Input      string    `json:"input,omitempty"`
+	Response   string    `json:"response,omitempty"`
+	Command    string    `json:"command,omitempty"`
+	Risk       string    `json:"risk,omitempty"`
+	Decision   string    `json:"decision,omitempty"`
+	ExitStatus int       `json:"exit_status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	PrevHash   string    `json:"prev_hash"`
+	Hash       string    `json:"hash,omitempty"`
+}
+
+// computeHash 计算记录的哈希，计算时不包含 Hash 字段本身
+func (r Record) computeHash() (string, error) {
+	r.Hash = ""
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Logger 是只追加的审计日志，单个文件超过 maxSize 字节后轮转，最多保留 maxFiles 个历史文件
+type Logger struct {
+	mu       sync.Mutex
+	path     string
+	lockPath string
+	maxSize  int64
+	maxFiles int
+	user     string
+	host     string
+}
+
+// NewLogger 创建保存在 dir 目录下的审计日志
+func NewLogger(dir string, maxSize int64, maxFiles int) (*Logger, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %v", err)
+	}
+	l := &Logger{
+		path:     filepath.Join(dir, "audit.jsonl"),
+		lockPath: filepath.Join(dir, "audit.lock"),
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+		user:     currentUser(),
+	}
+	l.host, _ = os.Hostname()
+	return l, nil
+}
+
+// Write 补全用户、主机和哈希后追加一条记录。
+// 写入期间持有文件锁，并在锁内从日志末尾读取前一条记录的哈希，其他进程同时写入时哈希链也不会分叉
+func (l *Logger) Write(r Record) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unlock, err := lockFile(l.lockPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	r.Time = r.Time.UTC()
+	r.User = l.user
+	r.Host = l.host
+	if r.PrevHash, err = l.lastHash(); err != nil {
+		return err
+	}
+	hash, err := r.computeHash()
+	if err != nil {
+		return fmt.Errorf("failed to hash audit record: %v", err)
+	}
+	r.Hash = hash
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %v", err)
+	}
+	if err := l.rotate(int64(len(data) + 1)); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %v", err)
+	}
+	return nil
+}
+
+// lastHash 返回哈希链末端的哈希，当前文件为空时（刚轮转过）哈希链末端在最近的历史文件中
+func (l *Logger) lastHash() (string, error) {
+	for _, path := range []string{l.path, rotatedPath(l.path, 1)} {
+		line, err := lastLine(path)
+		if err != nil {
+			return "", err
+		}
+		if line == nil {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(line, &r); err != nil {
+			return "", fmt.Errorf("%s: failed to parse last audit record: %v", filepath.Base(path), err)
+		}
+		return r.Hash, nil
+	}
+	return "", nil
+}
+
+// lastLine 从文件末尾向前读取最后一个非空行，文件不存在或为空时返回 nil
+func lastLine(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %v", err)
+	}
+
+	const chunk = 64 * 1024
+	var tail []byte
+	for offset := info.Size(); offset > 0; {
+		n := min(int64(chunk), offset)
+		offset -= n
+		block := make([]byte, n)
+		if _, err := f.ReadAt(block, offset); err != nil {
+			return nil, fmt.Errorf("failed to read audit log: %v", err)
+		}
+		tail = append(block, tail...)
+		trimmed := bytes.TrimRight(tail, "\r\n\t ")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], nil
+		}
+		if offset == 0 && len(trimmed) > 0 {
+			return trimmed, nil
+		}
+	}
+	return nil, nil
+}
+
+// rotate 在写入 n 字节后会超过 maxSize 时轮转文件：audit.jsonl -> audit.jsonl.1 -> audit.jsonl.2 ...
+func (l *Logger) rotate(n int64) error {
+	if l.maxSize <= 0 {
+		return nil
+	}
+	info, err := os.Stat(l.path)
+	if err != nil || info.Size() == 0 || info.Size()+n <= l.maxSize {
+		return nil
+	}
+
+	os.Remove(rotatedPath(l.path, l.maxFiles))
+	for i := l.maxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(rotatedPath(l.path, i), rotatedPath(l.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate audit log: %v", err)
+		}
+	}
+	if l.maxFiles == 0 {
+		return os.Remove(l.path)
+	}
+	if err := os.Rename(l.path, rotatedPath(l.path, 1)); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %v", err)
+	}
+	return nil
+}
+
+// VerifyResult 是审计日志的校验结果
+type VerifyResult struct {
+	Files   int
+	Records int
+	// Anchor 是最早一条记录的 PrevHash，非空表示更早的历史文件已被轮转删除
+	Anchor string
+}
+
+// Verify 按时间顺序校验所有文件中的哈希链，返回第一处不一致的位置
+func (l *Logger) Verify() (*VerifyResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unlock, err := lockFile(l.lockPath)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var paths []string
+	for i := l.maxFiles; i >= 1; i-- {
+		if _, err := os.Stat(rotatedPath(l.path, i)); err == nil {
+			paths = append(paths, rotatedPath(l.path, i))
+		}
+	}
+	paths = append(paths, l.path)
+
+	result := &VerifyResult{}
+	prev := ""
+	first := true
+	for _, path := range paths {
+		records, err := readRecords(path)
+		if err != nil {
+			return result, err
+		}
+		if len(records) > 0 {
+			result.Files++
+		}
+		for i, r := range records {
+			where := fmt.Sprintf("%s:%d", filepath.Base(path), i+1)
+			if first {
+				result.Anchor = r.PrevHash
+				first = false
+			} else if r.PrevHash != prev {
+				return result, fmt.Errorf("%s: prev_hash does not match previous record, records may have been removed or reordered", where)
+			}
+			hash, err := r.computeHash()
+			if err != nil {
+				return result, fmt.Errorf("%s: %v", where, err)
+			}
+			if hash != r.Hash {
+				return result, fmt.Errorf("%s: hash mismatch, record has been modified", where)
+			}
+			prev = r.Hash
+			result.Records++
+		}
+	}
+	return result, nil
+}
+
+// readRecords 读取一个审计文件中的全部记录，文件不存在时返回空
+func readRecords(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: failed to parse audit record: %v", filepath.Base(path), line, err)
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %v", err)
+	}
+	return records, nil
+}
+
+// rotatedPath 返回第 n 个历史文件的路径
+func rotatedPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// currentUser 返回当前操作系统用户名
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,201 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// writeRecords 用 logger 依次写入 n 条执行记录
+func writeRecords(t *testing.T, l *Logger, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := l.Write(Record{Type: TypeExecution, Command: fmt.Sprintf("kubectl get pods #%d", i)}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(lines []string) []string
+		wantErr string
+	}{
+		{
+			name:   "intact chain",
+			tamper: func(lines []string) []string { return lines },
+		},
+		{
+			name: "modified record",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], "#1", "#9", 1)
+				return lines
+			},
+			wantErr: "audit.jsonl:2: hash mismatch",
+		},
+		{
+			name: "removed record",
+			tamper: func(lines []string) []string {
+				return append(lines[:1:1], lines[2:]...)
+			},
+			wantErr: "audit.jsonl:2: prev_hash does not match",
+		},
+		{
+			name: "reordered records",
+			tamper: func(lines []string) []string {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			wantErr: "audit.jsonl:2: prev_hash does not match",
+		},
+		{
+			name: "removed first record",
+			tamper: func(lines []string) []string {
+				return lines[1:]
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			l, err := NewLogger(dir, 0, 0)
+			if err != nil {
+				t.Fatalf("NewLogger() error = %v", err)
+			}
+			writeRecords(t, l, 4)
+
+			path := filepath.Join(dir, "audit.jsonl")
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := tt.tamper(strings.Split(strings.TrimSpace(string(data)), "\n"))
+			if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := l.Verify()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				if result.Records != len(lines) {
+					t.Errorf("Verify() records = %d, want %d", result.Records, len(lines))
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyAcrossRotation(t *testing.T) {
+	dir := t.TempDir()
+	l, err := NewLogger(dir, 600, 2)
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	writeRecords(t, l, 12)
+
+	if _, err := os.Stat(filepath.Join(dir, "audit.jsonl.2")); err != nil {
+		t.Fatalf("expected rotated files: %v", err)
+	}
+	result, err := l.Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Files != 3 {
+		t.Errorf("Verify() files = %d, want 3", result.Files)
+	}
+	if result.Anchor == "" {
+		t.Error("Verify() anchor is empty, want the hash of a rotated-out record")
+	}
+}
+
+func TestConcurrentLoggersKeepOneChain(t *testing.T) {
+	dir := t.TempDir()
+	// 两个 Logger 模拟同时写入同一目录的两个进程
+	var loggers []*Logger
+	for i := 0; i < 2; i++ {
+		l, err := NewLogger(dir, 4096, 20)
+		if err != nil {
+			t.Fatalf("NewLogger() error = %v", err)
+		}
+		loggers = append(loggers, l)
+	}
+
+	// 交替写入时每条记录都要链接到另一个 Logger 写入的记录
+	writeRecords(t, loggers[0], 1)
+	writeRecords(t, loggers[1], 1)
+	writeRecords(t, loggers[0], 1)
+
+	var wg sync.WaitGroup
+	for _, l := range loggers {
+		wg.Add(1)
+		go func(l *Logger) {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				if err := l.Write(Record{Type: TypePrompt, Input: "查看 pod"}); err != nil {
+					t.Errorf("Write() error = %v", err)
+				}
+			}
+		}(l)
+	}
+	wg.Wait()
+
+	result, err := loggers[0].Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Records != 53 || result.Anchor != "" {
+		t.Errorf("Verify() records = %d, anchor = %q, want 53 records and no anchor", result.Records, result.Anchor)
+	}
+}
+
+func TestLastLine(t *testing.T) {
+	long := strings.Repeat("x", 200*1024)
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		missing bool
+	}{
+		{name: "missing file", missing: true},
+		{name: "empty file", content: ""},
+		{name: "single line without newline", content: "a", want: "a"},
+		{name: "trailing newlines", content: "a\nb\n\n", want: "b"},
+		{name: "line longer than a read chunk", content: "a\n" + long + "\n", want: long},
+		{name: "only whitespace", content: "\n \n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.jsonl")
+			if !tt.missing {
+				if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := lastLine(path)
+			if err != nil {
+				t.Fatalf("lastLine() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("lastLine() = %q, want %q", truncate(string(got)), truncate(tt.want))
+			}
+		})
+	}
+}
+
+// truncate 截断过长的字符串，避免失败信息过长
+func truncate(s string) string {
+	if len(s) > 20 {
+		return s[:20] + "..."
+	}
+	return s
+}
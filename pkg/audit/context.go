@@ -0,0 +1,29 @@
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// requestKey 是在 context 中保存当前请求的键
+type requestKey struct{}
+
+// request 关联同一条自然语言输入产生的模型调用与命令执行
+type request struct {
+	id    string
+	input string
+}
+
+// WithRequest 为一条自然语言输入生成请求 ID 并保存到 context 中
+func WithRequest(ctx context.Context, input string) context.Context {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return context.WithValue(ctx, requestKey{}, request{id: hex.EncodeToString(b), input: input})
+}
+
+// RequestFrom 返回 context 中的请求 ID 与自然语言输入
+func RequestFrom(ctx context.Context) (id, input string) {
+	r, _ := ctx.Value(requestKey{}).(request)
+	return r.id, r.input
+}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package audit
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile 以排他方式锁定 path，阻塞直到获得锁，返回释放锁的函数；
+// 多个 kubectl-ai 进程写同一份审计日志时由它保证哈希链不分叉
+func lockFile(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit lock: %v", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock audit log: %v", err)
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
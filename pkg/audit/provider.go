@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourusername/kubectl-ai/pkg/config"
+	"github.com/yourusername/kubectl-ai/pkg/llm"
+)
+
+// Provider 在 llm.Provider 外层记录每次命令生成的输入与模型响应
+type Provider struct {
+	llm.Provider
+	logger *Logger
+}
+
+// WrapProvider 返回记录审计日志的 Provider，logger 为空时直接返回原 Provider
+func WrapProvider(provider llm.Provider, logger *Logger) llm.Provider {
+	if logger == nil {
+		return provider
+	}
+	return &Provider{Provider: provider, logger: logger}
+}
+
+// TranslateCommand 调用大模型转换命令并记录审计日志
+func (p *Provider) TranslateCommand(ctx context.Context, conv *llm.Conversation, naturalCommand string) (string, error) {
+	start := time.Now()
+	response, err := p.Provider.TranslateCommand(ctx, conv, naturalCommand)
+	p.record(ctx, naturalCommand, response, start, err)
+	return response, err
+}
+
+// RefineCommand 根据收集到的信息重新生成命令并记录审计日志
+func (p *Provider) RefineCommand(ctx context.Context, conv *llm.Conversation, naturalCommand string, observations []llm.Observation) (string, error) {
+	start := time.Now()
+	response, err := p.Provider.RefineCommand(ctx, conv, naturalCommand, observations)
+	p.record(ctx, naturalCommand, response, start, err)
+	return response, err
+}
+
+// RepairCommand 让大模型修正失败的命令并记录审计日志
+func (p *Provider) RepairCommand(ctx context.Context, naturalCommand, failedCommand, errorOutput string) (string, error) {
+	start := time.Now()
+	response, err := p.Provider.RepairCommand(ctx, naturalCommand, failedCommand, errorOutput)
+	p.record(ctx, naturalCommand, response, start, err)
+	return response, err
+}
+
+// record 写入一条 prompt 类型的审计记录，写入失败只记录日志
+func (p *Provider) record(ctx context.Context, input, response string, start time.Time, err error) {
+	id, _ := RequestFrom(ctx)
+	r := Record{
+		Time:       start,
+		Type:       TypePrompt,
+		RequestID:  id,
+		Input:      input,
+		Response:   response,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		r.ExitStatus = 1
+		r.Error = err.Error()
+	}
+	if err := p.logger.Write(r); err != nil {
+		config.Logger.WithError(err).Warn("Failed to write audit record")
+	}
+}
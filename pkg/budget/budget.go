@@ -0,0 +1,176 @@
+package budget
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/yourusername/kubectl-ai/pkg/config"
+)
+
+// EstimateTokens 粗略估算文本的 token 数：中日韩字符按每字一个 token，其余字符按每 4 个字符一个 token
+func EstimateTokens(text string) int {
+	cjk, other := 0, 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+			unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// Report 描述一次裁剪丢弃了多少内容
+type Report struct {
+	OriginalTokens int
+	KeptTokens     int
+	OriginalLines  int
+	DroppedLines   int
+}
+
+// Budgeter 将命令输出裁剪到指定的 token 预算以内
+type Budgeter struct {
+	maxTokens int
+}
+
+// New 创建 token 预算为 maxTokens 的 Budgeter，maxTokens 不大于 0 时不裁剪
+func New(maxTokens int) *Budgeter {
+	return &Budgeter{maxTokens: maxTokens}
+}
+
+// MaxTokens 返回 token 预算
+func (b *Budgeter) MaxTokens() int {
+	return b.maxTokens
+}
+
+// failurePattern 匹配表示异常状态的行，裁剪时优先保留
+var failurePattern = regexp.MustCompile(`(?i)(error|fail|crashloop|backoff|errimagepull|oomkilled|evicted|pending|unknown|notready|terminating|unhealthy|warning|denied|forbidden|not found|timeout|refused)`)
+
+// headerPattern 匹配 kubectl 表格输出的表头，如 NAMESPACE   NAME   READY   STATUS
+var headerPattern = regexp.MustCompile(`^[A-Z][A-Z0-9()/-]*(\s{2,}[A-Z][A-Z0-9()/ -]*)*\s*$`)
+
+// Fit 将输出裁剪到 limit 个 token 以内（limit 不大于 0 时使用 Budgeter 的预算）：
+// 保留表头与异常行，其余行从开头和结尾各取一部分，被省略的行以一行摘要代替
+func (b *Budgeter) Fit(output string, limit int) (string, Report) {
+	if limit <= 0 {
+		limit = b.maxTokens
+	}
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	report := Report{
+		OriginalTokens: EstimateTokens(output),
+		OriginalLines:  len(lines),
+	}
+	if limit <= 0 || report.OriginalTokens <= limit {
+		report.KeptTokens = report.OriginalTokens
+		return output, report
+	}
+
+	// 单行超长的输出（如压缩的 JSON）按字符截断
+	if len(lines) == 1 {
+		kept := truncateRunes(lines[0], limit)
+		report.KeptTokens = EstimateTokens(kept)
+		b.log(report)
+		return kept, report
+	}
+
+	keep := make([]bool, len(lines))
+	used := 0
+	// 预留摘要行的空间
+	remaining := limit - limit/10
+	take := func(i int) bool {
+		if keep[i] {
+			return true
+		}
+		cost := EstimateTokens(lines[i]) + 1
+		if used+cost > remaining {
+			return false
+		}
+		keep[i] = true
+		used += cost
+		return true
+	}
+
+	// 1. 表头
+	if headerPattern.MatchString(lines[0]) {
+		take(0)
+	}
+	// 2. 开头与结尾的少量行，保证输出的整体形状
+	for i := 0; i < 3 && i < len(lines); i++ {
+		take(i)
+		take(len(lines) - 1 - i)
+	}
+	// 3. 异常行，最多使用一半的预算
+	for i, line := range lines {
+		if used > remaining/2 {
+			break
+		}
+		if failurePattern.MatchString(line) {
+			take(i)
+		}
+	}
+	// 4. 剩余预算交替从开头和结尾补充
+	for head, tail := 0, len(lines)-1; head <= tail; head, tail = head+1, tail-1 {
+		if !take(head) || !take(tail) {
+			break
+		}
+	}
+
+	var sb strings.Builder
+	skipped := 0
+	flush := func() {
+		if skipped > 0 {
+			fmt.Fprintf(&sb, "...（省略 %d 行）...\n", skipped)
+			report.DroppedLines += skipped
+			skipped = 0
+		}
+	}
+	for i, line := range lines {
+		if !keep[i] {
+			skipped++
+			continue
+		}
+		flush()
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	flush()
+
+	kept := sb.String()
+	report.KeptTokens = EstimateTokens(kept)
+	b.log(report)
+	return kept, report
+}
+
+// log 在调试日志中记录裁剪丢弃的内容
+func (b *Budgeter) log(report Report) {
+	config.Logger.WithFields(map[string]interface{}{
+		"original_tokens": report.OriginalTokens,
+		"kept_tokens":     report.KeptTokens,
+		"dropped_tokens":  report.OriginalTokens - report.KeptTokens,
+		"original_lines":  report.OriginalLines,
+		"dropped_lines":   report.DroppedLines,
+	}).Debug("Output trimmed to fit token budget")
+}
+
+// truncateRunes 保留单行文本的开头和结尾，使其连同省略提示不超过 limit 个 token
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	// 先按每 4 个字符一个 token 估算可保留的字符数，超出预算时逐步减少
+	keep := min(limit*4, len(runes))
+	if keep == len(runes) && EstimateTokens(text) <= limit {
+		return text
+	}
+	for ; keep > 0; keep = keep * 3 / 4 {
+		head := keep * 2 / 3
+		tail := keep - head
+		kept := fmt.Sprintf("%s\n...（省略 %d 个字符）...\n%s",
+			string(runes[:head]), len(runes)-keep, string(runes[len(runes)-tail:]))
+		if EstimateTokens(kept) <= limit {
+			return kept
+		}
+	}
+	return fmt.Sprintf("...（省略 %d 个字符）...", len(runes))
+}
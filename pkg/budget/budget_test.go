@@ -0,0 +1,126 @@
+package budget
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{text: "", want: 0},
+		{text: "abcd", want: 1},
+		{text: "abcde", want: 2},
+		{text: "命名空间", want: 4},
+		{text: "pod 重启", want: 3},
+		{text: "ポッド", want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := EstimateTokens(tt.text); got != tt.want {
+				t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+// podTable 生成 n 行 kubectl get pods 输出，第 failing 行的 Pod 处于 CrashLoopBackOff
+func podTable(n, failing int) string {
+	var sb strings.Builder
+	sb.WriteString("NAME          READY   STATUS    RESTARTS   AGE\n")
+	for i := 0; i < n; i++ {
+		status := "Running"
+		if i == failing {
+			status = "CrashLoopBackOff"
+		}
+		fmt.Fprintf(&sb, "web-%04d      1/1     %s   0          5d\n", i, status)
+	}
+	return sb.String()
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxTokens int
+		limit     int
+		output    string
+		unchanged bool
+		// contains 是裁剪后必须保留的内容
+		contains []string
+	}{
+		{
+			name:      "within budget",
+			maxTokens: 1000,
+			output:    podTable(5, -1),
+			unchanged: true,
+		},
+		{
+			name:      "no budget",
+			output:    podTable(500, -1),
+			unchanged: true,
+		},
+		{
+			name:      "keeps header and failures",
+			maxTokens: 200,
+			output:    podTable(500, 250),
+			contains:  []string{"NAME          READY", "web-0250      1/1     CrashLoopBackOff", "web-0000", "web-0499", "省略"},
+		},
+		{
+			name:      "limit overrides budget",
+			maxTokens: 100000,
+			limit:     200,
+			output:    podTable(500, -1),
+			contains:  []string{"NAME          READY", "省略"},
+		},
+		{
+			name:      "single long line",
+			maxTokens: 50,
+			output:    `{"items":[` + strings.Repeat(`{"name":"web"},`, 200) + `{"name":"last"}]}`,
+			contains:  []string{`{"items":[`, `"last"}]}`, "省略"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, report := New(tt.maxTokens).Fit(tt.output, tt.limit)
+			if tt.unchanged {
+				if got != tt.output {
+					t.Errorf("Fit() changed output within budget")
+				}
+				if report.KeptTokens != report.OriginalTokens {
+					t.Errorf("Fit() kept %d of %d tokens, want all", report.KeptTokens, report.OriginalTokens)
+				}
+				return
+			}
+			limit := tt.limit
+			if limit <= 0 {
+				limit = tt.maxTokens
+			}
+			if report.KeptTokens > limit {
+				t.Errorf("Fit() kept %d tokens, want at most %d", report.KeptTokens, limit)
+			}
+			if report.KeptTokens != EstimateTokens(got) {
+				t.Errorf("Fit() report.KeptTokens = %d, want %d", report.KeptTokens, EstimateTokens(got))
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(got, s) {
+					t.Errorf("Fit() output does not contain %q:\n%s", s, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFitReportsDroppedLines(t *testing.T) {
+	output := podTable(300, -1)
+	got, report := New(150).Fit(output, 0)
+	keptLines := strings.Count(got, "\n") - strings.Count(got, "省略")
+	if report.OriginalLines != 301 {
+		t.Errorf("report.OriginalLines = %d, want 301", report.OriginalLines)
+	}
+	if report.DroppedLines+keptLines != report.OriginalLines {
+		t.Errorf("dropped %d + kept %d lines, want %d", report.DroppedLines, keptLines, report.OriginalLines)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
@@ -12,38 +15,158 @@ import (
 // Logger 是全局的日志实例
 var Logger = logrus.New()
 
+// 支持的大模型提供方
+const (
+	ProviderDeepSeek  = "deepseek"
+	ProviderOpenAI    = "openai"
+	ProviderOllama    = "ollama"
+	ProviderAnthropic = "anthropic"
+)
+
+// apiKeyEnvs 是各提供方专用的 API Key 环境变量
+var apiKeyEnvs = map[string]string{
+	ProviderDeepSeek:  "DEEPSEEK_API_KEY",
+	ProviderOpenAI:    "OPENAI_API_KEY",
+	ProviderAnthropic: "ANTHROPIC_API_KEY",
+}
+
 // Config 存储应用配置
 type Config struct {
-	DeepseekAPIKey string
-	AutoExecute    bool
-	EnableChat    bool
-	Debug         bool
-	LogLevel      string
+	Provider string
+	APIKey   string
+	// Model 是默认的模型端点与采样参数
+	Model ModelProfile
+	// Profiles 是按用途（translate、explain 等）合并后的模型配置
+	Profiles    map[string]ModelProfile
+	AutoExecute bool
+	EnableChat  bool
+	Debug       bool
+	LogLevel    string
+	// ChatSummaryTokens 是多轮对话历史触发摘要的 token 数，0 表示只保留最近的若干条消息
+	ChatSummaryTokens int
+	// AgentMaxSteps 是信息收集循环的最大轮数
+	AgentMaxSteps int
+	// OutputTokenBudget 是每次发送给模型的命令输出的最大 token 数
+	OutputTokenBudget int
+	// RepairMaxAttempts 是命令执行失败后自动修正的最大次数，0 表示不修正
+	RepairMaxAttempts int
+	// PolicyRules 是按顺序匹配的命令策略规则
+	PolicyRules []PolicyRule
+	// ProtectedContexts 与 ProtectedNamespaces 是受保护的 kube-context 与命名空间（glob 通配符），
+	// 其中的写操作总是要求输入 context 名称确认
+	ProtectedContexts   []string
+	ProtectedNamespaces []string
+	// DataDir 是撤销日志等本地数据的存放目录
+	DataDir string
+	// AuditEnabled 表示是否记录审计日志
+	AuditEnabled bool
+	// AuditMaxSize 是单个审计文件轮转前的最大字节数
+	AuditMaxSize int64
+	// AuditMaxFiles 是保留的历史审计文件个数
+	AuditMaxFiles int
+	// RedactEnabled 表示发送给大模型之前是否屏蔽敏感信息
+	RedactEnabled bool
+	// RedactPatterns 是额外需要屏蔽的正则表达式
+	RedactPatterns []string
+	// ModelPrices 是按模型名称配置的每百万 token 价格
+	ModelPrices map[string]ModelPrice
+	// MonthlySpendCap 是每月费用上限，超出后拒绝新的请求，0 表示不限制
+	MonthlySpendCap float64
+
+	// 以下字段只能通过命令行全局参数设置
+	// KubeContext 与 Namespace 是未显式指定时命令默认作用的 kube-context 与命名空间
+	KubeContext string
+	Namespace   string
+	// AssumeYes 表示自动确认执行和修正，受保护的目标与 confirm 策略规则仍然要求确认
+	AssumeYes bool
+	// DryRun 表示只执行只读命令，其余命令只显示并预演而不执行
+	DryRun bool
+	// RequestTimeout 是等待大模型响应的超时时间
+	RequestTimeout time.Duration
+	// MaxRetries 是大模型请求遇到限流、服务端错误或网络错误时的最大重试次数
+	MaxRetries int
+	// RateLimit 是每分钟最多发送的大模型请求数，0 表示不限制
+	RateLimit int
+}
+
+// ModelPrice 是模型每百万 token 的价格，CacheHit 为 0 时命中缓存的输入按 Prompt 计价
+type ModelPrice struct {
+	Prompt     float64 `yaml:"prompt"`
+	Completion float64 `yaml:"completion"`
+	CacheHit   float64 `yaml:"cache_hit"`
 }
 
 // YAMLConfig 表示配置文件的结构
 type YAMLConfig struct {
+	LLM struct {
+		Provider     string `yaml:"provider"`
+		APIKey       string `yaml:"api_key"`
+		ModelProfile `yaml:",inline"`
+		Profiles     map[string]ModelProfile `yaml:"profiles"`
+		Timeout      int                     `yaml:"timeout"`
+		MaxRetries   *int                    `yaml:"max_retries"`
+		RateLimit    int                     `yaml:"rate_limit"`
+	} `yaml:"llm"`
+	// Deepseek 为兼容旧配置保留，llm.api_key 未设置时使用
 	Deepseek struct {
 		APIKey string `yaml:"api_key"`
 	} `yaml:"deepseek"`
 	AutoExecute bool   `yaml:"auto_execute"`
-	EnableChat bool   `yaml:"enable_chat"`
-	LogLevel   string `yaml:"log_level"`
+	EnableChat  bool   `yaml:"enable_chat"`
+	LogLevel    string `yaml:"log_level"`
+	Chat        struct {
+		SummaryTokens *int `yaml:"summary_tokens"`
+	} `yaml:"chat"`
+	Agent struct {
+		MaxSteps int `yaml:"max_steps"`
+	} `yaml:"agent"`
+	Budget struct {
+		MaxTokens int `yaml:"max_tokens"`
+	} `yaml:"budget"`
+	Repair struct {
+		MaxAttempts *int `yaml:"max_attempts"`
+	} `yaml:"repair"`
+	Policy struct {
+		Rules []PolicyRule `yaml:"rules"`
+	} `yaml:"policy"`
+	Protected struct {
+		Contexts   []string `yaml:"contexts"`
+		Namespaces []string `yaml:"namespaces"`
+	} `yaml:"protected"`
+	DataDir string `yaml:"data_dir"`
+	Audit   struct {
+		Enabled   *bool `yaml:"enabled"`
+		MaxSizeMB int   `yaml:"max_size_mb"`
+		MaxFiles  *int  `yaml:"max_files"`
+	} `yaml:"audit"`
+	Redact struct {
+		Enabled  *bool    `yaml:"enabled"`
+		Patterns []string `yaml:"patterns"`
+	} `yaml:"redact"`
+	Usage struct {
+		Prices     map[string]ModelPrice `yaml:"prices"`
+		MonthlyCap float64               `yaml:"monthly_cap"`
+	} `yaml:"usage"`
 }
 
-// LoadConfig 从配置文件和环境变量加载配置
-func LoadConfig() (*Config, error) {
+// LoadConfig 从配置文件和环境变量加载配置，configPath 为空时依次查找当前目录和上级目录的 config.yaml
+func LoadConfig(configPath string) (*Config, error) {
 	// 首先从配置文件加载默认值
 	var yamlConfig YAMLConfig
-	configPath := "./config.yaml"
-
-	// 如果在当前目录找不到配置文件，尝试在上级目录查找
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		configPath = filepath.Join("..", "config.yaml")
+	explicit := configPath != ""
+	if !explicit {
+		configPath = "./config.yaml"
+		// 如果在当前目录找不到配置文件，尝试在上级目录查找
+		if _, err := os.Stat(configPath); os.IsNotExist(err) {
+			configPath = filepath.Join("..", "config.yaml")
+		}
 	}
 
-	// 读取配置文件
+	// 读取配置文件，显式指定的配置文件必须存在
 	configData, err := os.ReadFile(configPath)
+	if err != nil && explicit {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
 	if err == nil {
 		if err := yaml.Unmarshal(configData, &yamlConfig); err != nil {
 			return nil, fmt.Errorf("failed to parse config file: %v", err)
@@ -51,13 +174,32 @@ func LoadConfig() (*Config, error) {
 	}
 
 	// 从环境变量读取配置，环境变量优先级高于配置文件
-	apiKey := os.Getenv("DEEPSEEK_API_KEY")
+	provider := os.Getenv("LLM_PROVIDER")
+	if provider == "" {
+		provider = yamlConfig.LLM.Provider
+	}
+	if provider == "" {
+		provider = ProviderDeepSeek // 默认提供方
+	}
+	provider = strings.ToLower(provider)
+	if provider != ProviderDeepSeek && provider != ProviderOpenAI &&
+		provider != ProviderOllama && provider != ProviderAnthropic {
+		return nil, fmt.Errorf("unsupported llm provider: %s", provider)
+	}
+
+	apiKey := os.Getenv("LLM_API_KEY")
+	if apiKey == "" && apiKeyEnvs[provider] != "" {
+		apiKey = os.Getenv(apiKeyEnvs[provider])
+	}
 	autoExecute := os.Getenv("AUTO_EXECUTE")
 	enableChat := os.Getenv("ENABLE_CHAT")
 	logLevel := os.Getenv("LOG_LEVEL")
 
 	// 如果环境变量未设置，使用配置文件中的值
 	if apiKey == "" {
+		apiKey = yamlConfig.LLM.APIKey
+	}
+	if apiKey == "" && provider == ProviderDeepSeek {
 		apiKey = yamlConfig.Deepseek.APIKey
 	}
 	if autoExecute == "" {
@@ -73,9 +215,142 @@ func LoadConfig() (*Config, error) {
 		logLevel = "info" // 默认日志级别
 	}
 
-	// 如果 API Key 仍然为空，返回错误
-	if apiKey == "" {
-		return nil, fmt.Errorf("DEEPSEEK_API_KEY not set in environment variables or config file")
+	// 如果 API Key 仍然为空，返回错误（Ollama 本地部署不需要 API Key）
+	if apiKey == "" && provider != ProviderOllama {
+		return nil, fmt.Errorf("%s not set in environment variables or config file", apiKeyEnvs[provider])
+	}
+
+	// 大模型请求的超时、重试与限流
+	timeout := yamlConfig.LLM.Timeout
+	if v := os.Getenv("LLM_TIMEOUT"); v != "" {
+		if timeout, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid LLM_TIMEOUT: %v", err)
+		}
+	}
+	if timeout <= 0 {
+		timeout = 60 // 默认 60 秒超时
+	}
+	maxRetries := 3 // 默认最多重试三次
+	if yamlConfig.LLM.MaxRetries != nil {
+		maxRetries = *yamlConfig.LLM.MaxRetries
+	}
+	if v := os.Getenv("LLM_MAX_RETRIES"); v != "" {
+		if maxRetries, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid LLM_MAX_RETRIES: %v", err)
+		}
+	}
+	rateLimit := yamlConfig.LLM.RateLimit
+	if v := os.Getenv("LLM_RATE_LIMIT"); v != "" {
+		if rateLimit, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid LLM_RATE_LIMIT: %v", err)
+		}
+	}
+
+	// 信息收集循环配置
+	agentMaxSteps := yamlConfig.Agent.MaxSteps
+	if v := os.Getenv("AGENT_MAX_STEPS"); v != "" {
+		if agentMaxSteps, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid AGENT_MAX_STEPS: %v", err)
+		}
+	}
+	if agentMaxSteps <= 0 {
+		agentMaxSteps = 3 // 默认最多收集三轮信息
+	}
+
+	// 命令输出的 token 预算
+	outputTokenBudget := yamlConfig.Budget.MaxTokens
+	if v := os.Getenv("OUTPUT_TOKEN_BUDGET"); v != "" {
+		if outputTokenBudget, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid OUTPUT_TOKEN_BUDGET: %v", err)
+		}
+	}
+	if outputTokenBudget <= 0 {
+		outputTokenBudget = 2000 // 默认每次最多发送约 2000 个 token 的命令输出
+	}
+
+	// 多轮对话历史的摘要阈值
+	chatSummaryTokens := 4000 // 默认历史超过约 4000 个 token 时压缩较早的轮次
+	if yamlConfig.Chat.SummaryTokens != nil {
+		chatSummaryTokens = *yamlConfig.Chat.SummaryTokens
+	}
+	if v := os.Getenv("CHAT_SUMMARY_TOKENS"); v != "" {
+		if chatSummaryTokens, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid CHAT_SUMMARY_TOKENS: %v", err)
+		}
+	}
+
+	// 自动修正配置
+	repairMaxAttempts := 2 // 默认最多修正两次
+	if yamlConfig.Repair.MaxAttempts != nil {
+		repairMaxAttempts = *yamlConfig.Repair.MaxAttempts
+	}
+	if v := os.Getenv("REPAIR_MAX_ATTEMPTS"); v != "" {
+		if repairMaxAttempts, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid REPAIR_MAX_ATTEMPTS: %v", err)
+		}
+	}
+
+	// 受保护的 kube-context 与命名空间
+	protectedContexts := yamlConfig.Protected.Contexts
+	if v := os.Getenv("PROTECTED_CONTEXTS"); v != "" {
+		protectedContexts = splitList(v)
+	}
+	protectedNamespaces := yamlConfig.Protected.Namespaces
+	if v := os.Getenv("PROTECTED_NAMESPACES"); v != "" {
+		protectedNamespaces = splitList(v)
+	}
+
+	// 本地数据目录，默认为 ~/.kubectl-ai
+	dataDir := os.Getenv("KUBECTL_AI_HOME")
+	if dataDir == "" {
+		dataDir = yamlConfig.DataDir
+	}
+	if dataDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %v", err)
+		}
+		dataDir = filepath.Join(home, ".kubectl-ai")
+	}
+
+	// 审计日志配置，默认开启
+	auditEnabled := true
+	if yamlConfig.Audit.Enabled != nil {
+		auditEnabled = *yamlConfig.Audit.Enabled
+	}
+	if v := os.Getenv("AUDIT_ENABLED"); v != "" {
+		auditEnabled = v == "true"
+	}
+	auditMaxSize := int64(yamlConfig.Audit.MaxSizeMB) << 20
+	if auditMaxSize <= 0 {
+		auditMaxSize = 10 << 20 // 默认 10MB 轮转
+	}
+	auditMaxFiles := 5 // 默认保留 5 个历史文件
+	if yamlConfig.Audit.MaxFiles != nil {
+		auditMaxFiles = *yamlConfig.Audit.MaxFiles
+	}
+
+	// 脱敏配置，默认开启
+	redactEnabled := true
+	if yamlConfig.Redact.Enabled != nil {
+		redactEnabled = *yamlConfig.Redact.Enabled
+	}
+	if v := os.Getenv("REDACT_ENABLED"); v != "" {
+		redactEnabled = v == "true"
+	}
+
+	// 费用上限
+	monthlySpendCap := yamlConfig.Usage.MonthlyCap
+	if v := os.Getenv("MONTHLY_SPEND_CAP"); v != "" {
+		if monthlySpendCap, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("invalid MONTHLY_SPEND_CAP: %v", err)
+		}
+	}
+
+	// 合并模型端点与采样参数
+	model, profiles, err := resolveProfiles(yamlConfig.LLM.ModelProfile, yamlConfig.LLM.Profiles)
+	if err != nil {
+		return nil, err
 	}
 
 	// 设置日志级别
@@ -91,9 +366,50 @@ func LoadConfig() (*Config, error) {
 	})
 
 	return &Config{
-		DeepseekAPIKey: apiKey,
-		AutoExecute:    autoExecute == "true",
-		EnableChat:    enableChat == "true",
-		LogLevel:      logLevel,
+		Provider: provider,
+		APIKey:   apiKey,
+		Model:    model,
+		Profiles: profiles,
+
+		RequestTimeout: time.Duration(timeout) * time.Second,
+		MaxRetries:     maxRetries,
+		RateLimit:      rateLimit,
+
+		AutoExecute: autoExecute == "true",
+		EnableChat:  enableChat == "true",
+		LogLevel:    logLevel,
+
+		ChatSummaryTokens: chatSummaryTokens,
+		AgentMaxSteps:     agentMaxSteps,
+		OutputTokenBudget: outputTokenBudget,
+
+		RepairMaxAttempts: repairMaxAttempts,
+		PolicyRules:       yamlConfig.Policy.Rules,
+
+		ProtectedContexts:   protectedContexts,
+		ProtectedNamespaces: protectedNamespaces,
+
+		DataDir: dataDir,
+
+		AuditEnabled:  auditEnabled,
+		AuditMaxSize:  auditMaxSize,
+		AuditMaxFiles: auditMaxFiles,
+
+		RedactEnabled:  redactEnabled,
+		RedactPatterns: yamlConfig.Redact.Patterns,
+
+		ModelPrices:     yamlConfig.Usage.Prices,
+		MonthlySpendCap: monthlySpendCap,
 	}, nil
 }
+
+// splitList 将逗号分隔的环境变量拆分为列表
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,24 @@
+package config
+
+// 策略规则的动作
+const (
+	PolicyAllow   = "allow"
+	PolicyDeny    = "deny"
+	PolicyConfirm = "confirm"
+)
+
+// PolicyRule 是一条命令策略规则，各字段之间为“且”的关系，字段内的多个值为“或”的关系，
+// 未设置的字段匹配任意值
+type PolicyRule struct {
+	Name   string `yaml:"name"`
+	Action string `yaml:"action"`
+	// Verbs 匹配 kubectl 动词，如 delete、apply
+	Verbs []string `yaml:"verbs"`
+	// Resources 匹配资源类型，支持 po、deploy 等简写
+	Resources []string `yaml:"resources"`
+	// Namespaces 与 Contexts 支持 glob 通配符，如 prod-*
+	Namespaces []string `yaml:"namespaces"`
+	Contexts   []string `yaml:"contexts"`
+	// Args 是匹配完整命令行的正则表达式
+	Args []string `yaml:"args"`
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 内置的模型配置档，未单独配置时使用 llm 下的默认值
+const (
+	ProfileTranslate = "translate"
+	ProfileExplain   = "explain"
+	ProfileSummarize = "summarize"
+)
+
+// ModelProfile 描述一组模型端点与采样参数
+type ModelProfile struct {
+	Endpoint    string   `yaml:"endpoint"`
+	Model       string   `yaml:"model"`
+	Temperature *float64 `yaml:"temperature"`
+	TopP        *float64 `yaml:"top_p"`
+	MaxTokens   int      `yaml:"max_tokens"`
+	Seed        *int     `yaml:"seed"`
+}
+
+// merge 返回以 p 为基础、被 override 中已设置字段覆盖后的配置档
+func (p ModelProfile) merge(override ModelProfile) ModelProfile {
+	if override.Endpoint != "" {
+		p.Endpoint = override.Endpoint
+	}
+	if override.Model != "" {
+		p.Model = override.Model
+	}
+	if override.Temperature != nil {
+		p.Temperature = override.Temperature
+	}
+	if override.TopP != nil {
+		p.TopP = override.TopP
+	}
+	if override.MaxTokens != 0 {
+		p.MaxTokens = override.MaxTokens
+	}
+	if override.Seed != nil {
+		p.Seed = override.Seed
+	}
+	return p
+}
+
+// profileFromEnv 读取以 prefix 开头的环境变量，如 LLM_MODEL、LLM_EXPLAIN_MODEL
+func profileFromEnv(prefix string) (ModelProfile, error) {
+	var p ModelProfile
+	p.Endpoint = os.Getenv(prefix + "ENDPOINT")
+	p.Model = os.Getenv(prefix + "MODEL")
+
+	if v := os.Getenv(prefix + "TEMPERATURE"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return p, fmt.Errorf("invalid %sTEMPERATURE: %v", prefix, err)
+		}
+		p.Temperature = &f
+	}
+	if v := os.Getenv(prefix + "TOP_P"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return p, fmt.Errorf("invalid %sTOP_P: %v", prefix, err)
+		}
+		p.TopP = &f
+	}
+	if v := os.Getenv(prefix + "MAX_TOKENS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return p, fmt.Errorf("invalid %sMAX_TOKENS: %v", prefix, err)
+		}
+		p.MaxTokens = n
+	}
+	if v := os.Getenv(prefix + "SEED"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return p, fmt.Errorf("invalid %sSEED: %v", prefix, err)
+		}
+		p.Seed = &n
+	}
+	return p, nil
+}
+
+// resolveProfiles 合并配置文件与环境变量，返回默认配置档和各命名配置档
+func resolveProfiles(base ModelProfile, profiles map[string]ModelProfile) (ModelProfile, map[string]ModelProfile, error) {
+	env, err := profileFromEnv("LLM_")
+	if err != nil {
+		return ModelProfile{}, nil, err
+	}
+	base = base.merge(env)
+
+	names := map[string]bool{ProfileTranslate: true, ProfileExplain: true, ProfileSummarize: true}
+	for name := range profiles {
+		names[name] = true
+	}
+
+	resolved := make(map[string]ModelProfile, len(names))
+	for name := range names {
+		env, err := profileFromEnv("LLM_" + strings.ToUpper(name) + "_")
+		if err != nil {
+			return ModelProfile{}, nil, err
+		}
+		resolved[name] = base.merge(profiles[name]).merge(env)
+	}
+	return base, resolved, nil
+}
@@ -0,0 +1,22 @@
+package deepseek
+
+import "github.com/yourusername/kubectl-ai/pkg/llm"
+
+// 错误类型已移至 llm 包，供各厂商共用；这里保留别名，已有的 errors.Is 判断无需修改
+var (
+	// ErrAuth 表示 API Key 无效或没有权限（401/403）
+	ErrAuth = llm.ErrAuth
+	// ErrQuota 表示账户余额或配额不足
+	ErrQuota = llm.ErrQuota
+	// ErrRateLimited 表示请求过于频繁（429）
+	ErrRateLimited = llm.ErrRateLimited
+	// ErrServer 表示服务端错误（5xx）
+	ErrServer = llm.ErrServer
+	// ErrNetwork 表示请求未能到达服务端或读取响应失败
+	ErrNetwork = llm.ErrNetwork
+	// ErrInvalidRequest 表示请求参数有误
+	ErrInvalidRequest = llm.ErrInvalidRequest
+)
+
+// APIError 是 API 返回非 200 状态码时的错误
+type APIError = llm.APIError
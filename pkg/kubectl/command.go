@@ -0,0 +1,179 @@
+package kubectl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 命令类型，对应 AI 响应中的行前缀
+const (
+	CommandInfo      = "INFO"
+	CommandDangerous = "DANGEROUS"
+	CommandNormal    = "NORMAL"
+)
+
+// 模型在结构化响应中声明的风险等级
+const (
+	RiskRead      = "read"
+	RiskWrite     = "write"
+	RiskDangerous = "dangerous"
+)
+
+// Command 表示从 AI 响应中解析出的一条命令
+type Command struct {
+	Type string
+	Cmd  string
+	// Argv 是结构化响应中的参数列表，行解析得到的命令为空
+	Argv      []string
+	Risk      string
+	Purpose   string
+	Namespace string
+}
+
+// stages 返回命令的管道阶段：结构化响应直接使用 Argv，否则按 shell 规则解析 Cmd
+func (c Command) stages() ([][]string, error) {
+	if len(c.Argv) == 0 {
+		return splitPipeline(c.Cmd)
+	}
+	return splitArgvPipeline(c.Argv)
+}
+
+// CommandSpec 是结构化响应中单条命令的格式
+type CommandSpec struct {
+	Argv      []string `json:"argv"`
+	Risk      string   `json:"risk"`
+	Purpose   string   `json:"purpose"`
+	Namespace string   `json:"namespace"`
+	// Gather 为 true 表示这是先收集信息的命令，对应行格式中的 [INFO]
+	Gather bool `json:"gather"`
+}
+
+// CommandPlan 是模型返回的结构化响应
+type CommandPlan struct {
+	Commands []CommandSpec `json:"commands"`
+}
+
+// ParseCommands 解析 AI 返回的内容，优先按结构化 JSON 解析，不是结构化响应时退回按行提取命令
+func ParseCommands(response string) ([]Command, error) {
+	if commands, ok, err := parsePlan(response); ok {
+		return commands, err
+	}
+	return parseLines(response)
+}
+
+// parsePlan 从响应中提取 JSON 并转换为命令，兼容 markdown 代码块包裹的 JSON。
+// 响应不是包含 commands 字段的 JSON 对象时 ok 为 false；是结构化响应但没有可执行的命令时返回错误，
+// 不再按行提取，避免把 JSON 文本当作命令执行
+func parsePlan(response string) (commands []Command, ok bool, err error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, false, nil
+	}
+
+	data := []byte(response[start : end+1])
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, false, nil
+	}
+	if _, ok := fields["commands"]; !ok {
+		return nil, false, nil
+	}
+	var plan CommandPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, true, fmt.Errorf("AI 响应中的命令格式无效: %v", err)
+	}
+
+	for _, spec := range plan.Commands {
+		if len(spec.Argv) == 0 {
+			continue
+		}
+		cmdType := CommandNormal
+		if spec.Gather {
+			cmdType = CommandInfo
+		} else if spec.Risk == RiskDangerous {
+			cmdType = CommandDangerous
+		}
+		commands = append(commands, Command{
+			Type:      cmdType,
+			Cmd:       QuoteArgv(spec.Argv),
+			Argv:      spec.Argv,
+			Risk:      spec.Risk,
+			Purpose:   spec.Purpose,
+			Namespace: spec.Namespace,
+		})
+	}
+	if len(commands) == 0 {
+		return nil, true, fmt.Errorf("AI 响应中没有可执行的 kubectl 命令")
+	}
+	return commands, true, nil
+}
+
+// parseLines 预处理 AI 返回的文本，按行提取实际命令
+func parseLines(response string) ([]Command, error) {
+	lines := strings.Split(response, "\n")
+	var commands []Command
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		// 跳过 markdown 代码块标记
+		if strings.HasPrefix(line, "```") {
+			continue
+		}
+		// 如果行以中文冒号结尾，说明是描述性文本，跳过
+		if strings.HasSuffix(line, "：") {
+			continue
+		}
+		// 如果行包含中文，说明是描述性文本，跳过
+		if containsChinese(line) {
+			continue
+		}
+		// 解析命令类型和实际命令
+		cmdType, actualCmd := parseCommand(line)
+		commands = append(commands, Command{Type: cmdType, Cmd: actualCmd})
+	}
+
+	// 如果没有提取到有效命令，返回错误
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("未能从 AI 响应中提取出有效的 kubectl 命令")
+	}
+	return commands, nil
+}
+
+// containsChinese 检查字符串是否包含中文字符
+func containsChinese(str string) bool {
+	for _, r := range str {
+		if r >= '\u4e00' && r <= '\u9fff' {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCommand 解析命令类型和实际命令
+func parseCommand(cmd string) (cmdType string, actualCmd string) {
+	cmd = strings.TrimSpace(cmd)
+	if strings.HasPrefix(cmd, "[INFO] ") {
+		return CommandInfo, strings.TrimPrefix(cmd, "[INFO] ")
+	}
+	if strings.HasPrefix(cmd, "[DANGEROUS] ") {
+		return CommandDangerous, strings.TrimPrefix(cmd, "[DANGEROUS] ")
+	}
+	return CommandNormal, cmd
+}
+
+// QuoteArgv 将参数列表拼接为可读的命令行，含空白或特殊字符的参数用单引号包裹
+func QuoteArgv(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`|&;<>(){}[]*?!#~") {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
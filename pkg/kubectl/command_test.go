@@ -0,0 +1,86 @@
+package kubectl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     []string
+		types    []string
+		wantErr  bool
+	}{
+		{
+			name:     "structured plan",
+			response: `{"commands":[{"argv":["kubectl","get","pods"],"risk":"read","gather":true},{"argv":["kubectl","delete","pod","web"],"risk":"dangerous"}]}`,
+			want:     []string{"kubectl get pods", "kubectl delete pod web"},
+			types:    []string{CommandInfo, CommandDangerous},
+		},
+		{
+			name:     "plan in markdown code block",
+			response: "```json\n{\"commands\":[{\"argv\":[\"kubectl\",\"get\",\"pods\",\"-l\",\"app in (a,b)\"]}]}\n```",
+			want:     []string{"kubectl get pods -l 'app in (a,b)'"},
+			types:    []string{CommandNormal},
+		},
+		{
+			name:     "empty plan",
+			response: `{"commands":[]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "plan with empty argv",
+			response: `{"commands":[{"argv":[],"risk":"read"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "plan with invalid field types",
+			response: `{"commands":[{"argv":"kubectl get pods"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "line format",
+			response: "[INFO] kubectl get pods\nkubectl scale deploy/api --replicas=3",
+			want:     []string{"kubectl get pods", "kubectl scale deploy/api --replicas=3"},
+			types:    []string{CommandInfo, CommandNormal},
+		},
+		{
+			name:     "line format with jsonpath braces",
+			response: "kubectl get pods -o jsonpath='{.items[*].metadata.name}'",
+			want:     []string{"kubectl get pods -o jsonpath='{.items[*].metadata.name}'"},
+			types:    []string{CommandNormal},
+		},
+		{
+			name:     "line format with empty json object",
+			response: "kubectl patch deploy/api -p '{}'",
+			want:     []string{"kubectl patch deploy/api -p '{}'"},
+			types:    []string{CommandNormal},
+		},
+		{
+			name:     "description only",
+			response: "无法确定要执行的命令：",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands, err := ParseCommands(tt.response)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCommands() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got, types []string
+			for _, c := range commands {
+				got = append(got, c.Cmd)
+				types = append(types, c.Type)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commands = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(types, tt.types) {
+				t.Errorf("types = %q, want %q", types, tt.types)
+			}
+		})
+	}
+}
@@ -1,181 +1,437 @@
 package kubectl
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/kubectl-ai/pkg/audit"
+	"github.com/yourusername/kubectl-ai/pkg/config"
 	"github.com/yourusername/kubectl-ai/pkg/utils"
 )
 
+// ErrCancelled 表示用户在确认提示中取消了命令执行
+var ErrCancelled = errors.New("用户取消了命令执行")
+
+// Result 是一条命令的执行结果：风险等级、确认结果，以及 kubectl 的标准输出、标准错误和退出码。
+// 命令未执行或因 kubectl 以外的原因失败时 ExitCode 为 -1
+type Result struct {
+	Command    string `json:"command" yaml:"command"`
+	Type       string `json:"type" yaml:"type"`
+	Risk       string `json:"risk,omitempty" yaml:"risk,omitempty"`
+	Decision   string `json:"decision,omitempty" yaml:"decision,omitempty"`
+	Context    string `json:"context,omitempty" yaml:"context,omitempty"`
+	Namespace  string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Stdout     string `json:"stdout" yaml:"stdout"`
+	Stderr     string `json:"stderr" yaml:"stderr"`
+	ExitCode   int    `json:"exit_code" yaml:"exit_code"`
+	Error      string `json:"error,omitempty" yaml:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms" yaml:"duration_ms"`
+}
+
 // Executor 代表 kubectl 命令执行器
 type Executor struct {
-    autoExecute bool
+	autoExecute bool
+	policy      *Policy
+	journal     *Journal
+	audit       *audit.Logger
+	// observer 在每条命令执行结束后以执行结果调用，用于结构化输出
+	observer func(Result)
+	// scope 是全局参数指定的默认 context 与命名空间
+	scope Scope
+	// dryRun 为 true 时只执行只读命令，其余命令只显示并预演
+	dryRun bool
+	// out 是执行计划、确认提示与执行过程等面向用户的输出
+	out io.Writer
+
+	// 受保护的 kube-context 与命名空间，其中的写操作总是要求输入 context 名称确认
+	protectedContexts   []string
+	protectedNamespaces []string
+
+	// 当前 kube-context 及各 context 默认命名空间的缓存
+	kubeContext *string
+	namespaces  map[string]string
 }
 
 // NewExecutor 创建新的 kubectl 执行器
-func NewExecutor(autoExecute bool) *Executor {
-    return &Executor{
-        autoExecute: autoExecute,
-    }
+func NewExecutor(cfg *config.Config) (*Executor, error) {
+	policy, err := NewPolicy(cfg.PolicyRules)
+	if err != nil {
+		return nil, err
+	}
+	for _, glob := range append(append([]string{}, cfg.ProtectedContexts...), cfg.ProtectedNamespaces...) {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid protected pattern %q: %v", glob, err)
+		}
+	}
+	e := &Executor{
+		autoExecute:         cfg.AutoExecute || cfg.AssumeYes,
+		policy:              policy,
+		scope:               Scope{Context: cfg.KubeContext, Namespace: cfg.Namespace},
+		dryRun:              cfg.DryRun,
+		protectedContexts:   cfg.ProtectedContexts,
+		protectedNamespaces: cfg.ProtectedNamespaces,
+		namespaces:          make(map[string]string),
+		out:                 os.Stdout,
+	}
+	if cfg.DataDir != "" {
+		e.journal = NewJournal(cfg.DataDir)
+	}
+	return e, nil
 }
 
 // ExecuteNaturalCommand 执行自然语言转换后的 kubectl 命令
 func (e *Executor) ExecuteNaturalCommand(ctx context.Context, kubectlCommand string) (string, error) {
-    // 预处理 AI 返回的内容，提取实际命令
-    lines := strings.Split(kubectlCommand, "\n")
-    var commands []string
-    for _, line := range lines {
-        line = strings.TrimSpace(line)
-        if line == "" {
-            continue
-        }
-        // 如果行以中文冒号结尾，说明是描述性文本，跳过
-        if strings.HasSuffix(line, "：") {
-            continue
-        }
-        // 如果行包含中文，说明是描述性文本，跳过
-        if containsChinese(line) {
-            continue
-        }
-        commands = append(commands, line)
-    }
-
-    // 如果没有提取到有效命令，返回错误
-    if len(commands) == 0 {
-        return "", fmt.Errorf("未能从 AI 响应中提取出有效的 kubectl 命令")
-    }
-
-    var lastOutput string
-    for _, cmd := range commands {
-        // 解析命令类型和实际命令
-        cmdType, actualCmd := parseCommand(cmd)
-
-        // 判断是否需要用户确认
-        needConfirm := false
-        var warningMsg string
-
-        // 非查询命令或危险命令需要确认
-        if !e.isQueryCommand(actualCmd) && !e.autoExecute {
-            needConfirm = true
-            if cmdType == "DANGEROUS" {
-                warningMsg = fmt.Sprintf("\n%s即将执行危险命令：%s\n", utils.Yellow("[警告] "), actualCmd)
-            } else {
-                warningMsg = fmt.Sprintf("\n%s即将执行非查询命令：%s\n", utils.Yellow("[警告] "), actualCmd)
-            }
-        }
-
-        // 如果需要确认，显示警告并获取用户确认
-        if needConfirm {
-            fmt.Print(warningMsg)
-            if !confirmExecution() {
-                return "", fmt.Errorf("用户取消了命令执行")
-            }
-        }
-
-        // 根据命令类型执行不同的操作
-        switch cmdType {
-        case "INFO":
-            // 执行信息收集命令
-            output, err := e.executeCommand(ctx, actualCmd)
-            if err != nil {
-                return "", fmt.Errorf("执行信息收集命令失败: %v", err)
-            }
-            fmt.Printf("\n%s收集到的信息：%s\n", utils.Green("[INFO] "), output)
-            lastOutput = output
-
-        default:
-            // 执行普通命令或危险命令
-            fmt.Printf("\n%s执行命令：%s\n", utils.Blue("[执行] "), actualCmd)
-            output, err := e.executeCommand(ctx, actualCmd)
-            if err != nil {
-                return "", fmt.Errorf("命令执行失败: %v", err)
-            }
-            lastOutput = output
-        }
-    }
-
-    return lastOutput, nil
-}
-
-// containsChinese 检查字符串是否包含中文字符
-func containsChinese(str string) bool {
-    for _, r := range str {
-        if r >= '\u4e00' && r <= '\u9fff' {
-            return true
-        }
-    }
-    return false
-}
-
-// parseCommand 解析命令类型和实际命令
-func parseCommand(cmd string) (cmdType string, actualCmd string) {
-    cmd = strings.TrimSpace(cmd)
-    if strings.HasPrefix(cmd, "[INFO] ") {
-        return "INFO", strings.TrimPrefix(cmd, "[INFO] ")
-    }
-    if strings.HasPrefix(cmd, "[DANGEROUS] ") {
-        return "DANGEROUS", strings.TrimPrefix(cmd, "[DANGEROUS] ")
-    }
-    return "NORMAL", cmd
-}
-
-// executeCommand 执行 kubectl 命令
-func (e *Executor) executeCommand(ctx context.Context, command string) (string, error) {
-    args := strings.Fields(command)
-    cmd := exec.CommandContext(ctx, "kubectl", args[1:]...)
-    output, err := cmd.CombinedOutput()
-    if err != nil {
-        // 如果命令执行失败，将错误输出和错误信息一起返回
-        return string(output), fmt.Errorf("%v\n%s", err, output)
-    }
-    return string(output), err
-}
-
-// confirmExecution 询问用户是否确认执行命令
-func confirmExecution() bool {
-    fmt.Print("是否确认执行此命令？(y/n): ")
-    var response string
-    fmt.Scanln(&response)
-    return strings.ToLower(response) == "y"
-}
-
-// isQueryCommand 判断是否为查询命令
-func (e *Executor) isQueryCommand(kubectlCommand string) bool {
-	// 标准化命令
-	command := strings.TrimSpace(kubectlCommand)
-	command = strings.TrimPrefix(command, "kubectl ")
-	parts := strings.Fields(command)
-	if len(parts) == 0 {
-		return false
+	commands, err := ParseCommands(kubectlCommand)
+	if err != nil {
+		return "", err
+	}
+	return e.Execute(ctx, commands)
+}
+
+// Execute 依次执行命令，返回最后一条命令的输出
+func (e *Executor) Execute(ctx context.Context, commands []Command) (string, error) {
+	var lastOutput string
+	for _, cmd := range commands {
+		output, err := e.Run(ctx, cmd)
+		if err != nil {
+			return "", err
+		}
+		lastOutput = output
+	}
+	return lastOutput, nil
+}
+
+// Run 执行单条命令，风险等级高于只读的命令在执行前需要用户确认
+func (e *Executor) Run(ctx context.Context, command Command) (string, error) {
+	// 每条命令重新解析目标 context 与命名空间，同一条命令的风险评估、策略、确认与快照使用相同的目标
+	e.forgetTarget()
+	defer e.forgetTarget()
+
+	// 记录命令、风险等级、确认结果、退出状态与耗时
+	start := time.Now()
+	result := &Result{Command: command.Cmd, Type: command.Type}
+	output, err := e.run(ctx, command, result)
+	e.report(ctx, start, result, err)
+	return output, err
+}
+
+// report 填入耗时与错误，写入审计日志并通知观察者
+func (e *Executor) report(ctx context.Context, start time.Time, result *Result, err error) {
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.ExitCode = exitStatus(err)
+		result.Error = err.Error()
 	}
-	action := parts[0]
 
-	// 查询类命令白名单
-	queryWhitelist := map[string]bool{
-		"get": true, "describe": true, "explain": true, "logs": true,
-		"top": true, "cluster-info": true, "attach": true, "exec": true,
-		"proxy": true, "cp": true, "auth": true, "debug": true, "events": true,
+	if e.audit != nil {
+		id, input := audit.RequestFrom(ctx)
+		record := audit.Record{
+			Time:       start,
+			Type:       audit.TypeExecution,
+			RequestID:  id,
+			Input:      input,
+			Command:    result.Command,
+			Risk:       result.Risk,
+			Decision:   result.Decision,
+			Context:    result.Context,
+			Namespace:  result.Namespace,
+			ExitStatus: result.ExitCode,
+			Error:      result.Error,
+			DurationMs: result.DurationMs,
+		}
+		if werr := e.audit.Write(record); werr != nil {
+			config.Logger.WithError(werr).Warn("Failed to write audit record")
+		}
+	}
+	if e.observer != nil {
+		e.observer(*result)
 	}
+}
 
-	// 高危写操作黑名单
-	writeBlacklist := map[string]bool{
-		"apply": true, "create": true, "delete": true, "patch": true,
-		"replace": true, "scale": true, "rollout": true, "taint": true,
-		"label": true, "annotate": true, "edit": true, "set": true,
+// run 执行单条命令，并将风险等级、目标、确认结果与命令输出填入执行结果
+func (e *Executor) run(ctx context.Context, command Command, result *Result) (string, error) {
+	stages, err := command.stages()
+	if err != nil {
+		return "", err
 	}
+	command, stages = e.applyScope(command, stages)
+	result.Command = command.Cmd
+
+	// 评估风险等级，模型声明的风险更高时以模型为准
+	assessment := e.Assess(command, stages[0])
+	result.Risk = assessment.Level.String()
+
+	// 同一条命令的审计、确认、预演与快照都使用这里解析得到的目标
+	var target Target
+	resolved := assessment.Level > RiskLevelRead || e.audit != nil || e.observer != nil
+	if resolved {
+		target = e.ResolveTarget(ctx, assessment)
+		result.Context, result.Namespace = target.Context, target.Namespace
+	}
+
+	// 按顺序匹配策略规则：deny 直接拒绝，allow 跳过确认，confirm 总是确认
+	decision := e.CheckPolicy(ctx, assessment, stages[0])
+	needConfirm := assessment.Level > RiskLevelRead && !e.autoExecute
+	switch decision.Action() {
+	case config.PolicyDeny:
+		result.Decision = audit.DecisionDenied
+		return "", fmt.Errorf("%w: %s 命中%s", ErrPolicyDenied, command.Cmd, decision)
+	case config.PolicyAllow:
+		needConfirm = false
+	case config.PolicyConfirm:
+		needConfirm = true
+		assessment.Reasons = append(assessment.Reasons, "命中"+decision.String())
+	}
+
+	if needConfirm && !resolved {
+		target = e.ResolveTarget(ctx, assessment)
+	}
+
+	// 受保护的 context 或命名空间中的写操作忽略 auto_execute 和 allow 规则，总是要求输入 context 名称
+	protected := assessment.Level >= RiskLevelWrite && e.hasProtected() && e.isProtected(target, assessment.AllNamespaces)
+
+	// dry-run 模式下只读以外的命令只显示目标并预演，不执行
+	if e.dryRun && assessment.Level > RiskLevelRead {
+		promptMu.Lock()
+		e.showPlan(ctx, command, assessment, target, stages[0])
+		fmt.Fprintf(e.out, "%s未执行：%s\n", utils.Yellow("[dry-run] "), command.Cmd)
+		promptMu.Unlock()
+		result.Decision = audit.DecisionDryRun
+		result.ExitCode = -1
+		return "", nil
+	}
+
+	// 如果需要确认，显示警告、目标 context 与命名空间并获取用户确认
+	if needConfirm || protected {
+		result.Decision = audit.DecisionCancelled
+		if !e.confirmPlan(ctx, command, assessment, target, stages[0], protected) {
+			return "", ErrCancelled
+		}
+		result.Decision = audit.DecisionConfirmed
+	} else {
+		result.Decision = audit.DecisionNotRequired
+	}
+
+	// 写操作执行前保存目标对象的快照，用于撤销
+	if assessment.Level >= RiskLevelWrite {
+		e.record(ctx, command, assessment, target, stages[0])
+	}
+
+	// 根据命令类型执行不同的操作
+	switch command.Type {
+	case CommandInfo:
+		// 执行信息收集命令
+		output, err := e.runPipeline(ctx, command, stages, result)
+		if err != nil {
+			return "", fmt.Errorf("执行信息收集命令失败: %w", err)
+		}
+		fmt.Fprintf(e.out, "\n%s收集到的信息：%s\n", utils.Green("[INFO] "), output)
+		return output, nil
+
+	default:
+		// 执行普通命令或危险命令
+		fmt.Fprintf(e.out, "\n%s执行命令：%s\n", utils.Blue("[执行] "), command.Cmd)
+		if command.Purpose != "" {
+			fmt.Fprintf(e.out, "%s%s\n", utils.Blue("[目的] "), command.Purpose)
+		}
+		output, err := e.runPipeline(ctx, command, stages, result)
+		if err != nil {
+			return "", fmt.Errorf("命令执行失败: %w", err)
+		}
+		return output, nil
+	}
+}
+
+// confirmPlan 显示执行计划并获取用户确认。
+// 显示计划与读取输入期间持有 promptMu，并发执行时其他命令的计划不会穿插其中，用户的回答总是对应刚显示的命令
+func (e *Executor) confirmPlan(ctx context.Context, command Command, assessment Assessment, target Target, argv []string, protected bool) bool {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+	e.showPlan(ctx, command, assessment, target, argv)
+	if protected {
+		fmt.Fprintf(e.out, "%s目标为受保护的 context 或命名空间\n", utils.Red("[受保护] "))
+		return e.confirmTyped(target.Context)
+	}
+	return e.confirmExecution()
+}
+
+// showPlan 显示命令的风险等级、目标 context 与命名空间及原因，写操作还会通过服务端预演显示将要发生的变化
+func (e *Executor) showPlan(ctx context.Context, command Command, assessment Assessment, target Target, argv []string) {
+	fmt.Fprintf(e.out, "\n%s即将执行命令：%s\n", assessment.Level.Label(), command.Cmd)
+	fmt.Fprintf(e.out, "  目标: context=%s namespace=%s\n", displayOrNone(target.Context), targetNamespace(target, assessment))
+	for _, reason := range assessment.Reasons {
+		fmt.Fprintf(e.out, "  - %s\n", reason)
+	}
+	if assessment.Level >= RiskLevelWrite {
+		e.Preview(ctx, assessment, argv)
+	}
+}
 
-	// 混合模式判断
-	if queryWhitelist[action] {
+// SetOutput 设置执行计划、确认提示与执行过程的输出位置，默认为标准输出
+func (e *Executor) SetOutput(w io.Writer) {
+	e.out = w
+}
+
+// Output 返回面向用户的输出位置，与执行器配合使用的 Agent 等也输出到这里
+func (e *Executor) Output() io.Writer {
+	return e.out
+}
+
+// SetObserver 设置执行结果的观察者，每条命令执行结束后调用，包括被拒绝、取消或预演的命令
+func (e *Executor) SetObserver(observer func(Result)) {
+	e.observer = observer
+}
+
+// SetAudit 设置记录命令执行的审计日志
+func (e *Executor) SetAudit(logger *audit.Logger) {
+	e.audit = logger
+}
+
+// exitStatus 返回命令执行错误对应的退出码，非 kubectl 退出导致的错误返回 -1
+func exitStatus(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// CheckPolicy 返回与命令匹配的策略规则
+func (e *Executor) CheckPolicy(ctx context.Context, assessment Assessment, argv []string) PolicyDecision {
+	var target Target
+	if e.policy.NeedsTarget() {
+		target = e.ResolveTarget(ctx, assessment)
+	}
+	return e.policy.Evaluate(assessment, argv, target)
+}
+
+// Assess 评估命令的风险等级，argv 为管道中 kubectl 阶段的参数
+func (e *Executor) Assess(command Command, argv []string) Assessment {
+	assessment := Classify(argv)
+	if declared := declaredLevel(command); declared > assessment.Level {
+		assessment.Level = declared
+		assessment.Reasons = append(assessment.Reasons, "模型将命令标记为 "+declared.String())
+	}
+	return assessment
+}
+
+// runKubectl 执行单条 kubectl 命令，分别返回标准输出和标准错误
+func (e *Executor) runKubectl(ctx context.Context, display string, args []string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "kubectl", args[1:]...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		// 如果命令执行失败，将错误输出和错误信息一起返回
+		output := stdout.String() + stderr.String()
+		return stdout.String(), stderr.String(), &CommandError{Command: display, Output: output, Err: err}
+	}
+	return stdout.String(), stderr.String(), nil
+}
+
+// CommandError 表示 kubectl 命令执行失败，Output 中包含 kubectl 的错误输出
+type CommandError struct {
+	Command string
+	Output  string
+	Err     error
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%v\n%s", e.Err, e.Output)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// hasProtected 判断是否配置了受保护的 context 或命名空间
+func (e *Executor) hasProtected() bool {
+	return len(e.protectedContexts) > 0 || len(e.protectedNamespaces) > 0
+}
+
+// isProtected 判断目标是否为受保护的 context 或命名空间，跨所有命名空间的命令视为命中受保护的命名空间。
+// 无法确定 context 或命名空间时按受保护处理，避免查询失败时跳过确认
+func (e *Executor) isProtected(target Target, allNamespaces bool) bool {
+	if len(e.protectedContexts) > 0 && (target.Context == "" || matchAny(e.protectedContexts, target.Context, globMatch)) {
 		return true
 	}
-	if writeBlacklist[action] {
+	if len(e.protectedNamespaces) == 0 {
 		return false
 	}
+	return allNamespaces || target.Namespace == "" || matchAny(e.protectedNamespaces, target.Namespace, globMatch)
+}
 
-	// 默认保守策略：未知命令视为非查询
-	return false
+// targetNamespace 返回用于显示的目标命名空间
+func targetNamespace(target Target, assessment Assessment) string {
+	if assessment.AllNamespaces {
+		return "<所有命名空间>"
+	}
+	return displayOrNone(target.Namespace)
 }
 
+// displayOrNone 在值为空时返回占位符
+func displayOrNone(s string) string {
+	if s == "" {
+		return "<未知>"
+	}
+	return s
+}
 
+// confirmTyped 要求用户输入 context 名称确认执行，context 未知时要求输入 yes，调用方需持有 promptMu
+func (e *Executor) confirmTyped(kubeContext string) bool {
+	expected := kubeContext
+	if expected == "" {
+		expected = "yes"
+	}
+	response := e.readLine(fmt.Sprintf("请输入 %s 确认执行: ", utils.Red(expected)))
+	return strings.TrimSpace(response) == expected
+}
 
+// confirmExecution 询问用户是否确认执行命令，调用方需持有 promptMu
+func (e *Executor) confirmExecution() bool {
+	return e.confirmYes("是否确认执行此命令？")
+}
+
+// Confirm 显示问题并等待用户输入 y/n，只有输入 y 时返回 true
+func (e *Executor) Confirm(question string) bool {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+	return e.confirmYes(question)
+}
+
+// confirmYes 读取用户对问题的 y/n 回答
+func (e *Executor) confirmYes(question string) bool {
+	response := e.readLine(question + "(y/n): ")
+	return strings.ToLower(strings.TrimSpace(response)) == "y"
+}
+
+// promptMu 保证并发执行的命令同一时间只有一个在显示执行计划或等待用户输入
+var promptMu sync.Mutex
+
+// lineReader 是交互模式下通过 SetLineReader 设置的行编辑器，为空时从标准输入读取
+var lineReader func(prompt string) string
+
+// readLine 显示提示并读取用户输入的一行
+func (e *Executor) readLine(prompt string) string {
+	if lineReader != nil {
+		return lineReader(prompt)
+	}
+	fmt.Fprint(e.out, prompt)
+	var response string
+	fmt.Scanln(&response)
+	return response
+}
+
+// SetLineReader 替换读取确认输入的方式。行编辑器带缓冲地读取标准输入并切换终端模式，
+// 使用行编辑器时确认输入也应由它读取，否则可能丢失用户的输入
+func SetLineReader(read func(prompt string) string) {
+	lineReader = read
+}
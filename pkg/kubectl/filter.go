@@ -0,0 +1,455 @@
+package kubectl
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// filter 是管道中的只读过滤器，在进程内处理上一阶段的输出
+type filter func(input string) (string, error)
+
+// filterBuilders 是允许出现在管道中的过滤器白名单
+var filterBuilders map[string]func(args []string) (filter, error)
+
+func init() {
+	filterBuilders = map[string]func(args []string) (filter, error){
+		"grep":  newGrep,
+		"egrep": newGrep,
+		"wc":    newWc,
+		"head":  func(args []string) (filter, error) { return newHeadTail(args, false) },
+		"tail":  func(args []string) (filter, error) { return newHeadTail(args, true) },
+		"sort":  newSort,
+		"uniq":  newUniq,
+		"cut":   newCut,
+		"awk":   newAwk,
+		"jq":    newJq,
+	}
+}
+
+// newFilter 根据管道阶段的参数创建过滤器，不在白名单中的命令返回错误
+func newFilter(stage []string) (filter, error) {
+	build, ok := filterBuilders[stage[0]]
+	if !ok {
+		return nil, fmt.Errorf("管道中不允许执行 %s，仅支持 grep、wc、head、tail、sort、uniq、cut、awk、jq 和 xargs kubectl", stage[0])
+	}
+	return build(stage[1:])
+}
+
+// splitLines 将输出拆分为行，忽略末尾的换行
+func splitLines(input string) []string {
+	input = strings.TrimSuffix(input, "\n")
+	if input == "" {
+		return nil
+	}
+	return strings.Split(input, "\n")
+}
+
+// joinLines 将行拼接为输出
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// newGrep 支持 grep 的 -i -v -c -n -w -E -F -e 参数
+func newGrep(args []string) (filter, error) {
+	var ignoreCase, invert, count, number, word, fixed bool
+	var pattern string
+	hasPattern := false
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-e" && i+1 < len(args) {
+			i++
+			pattern, hasPattern = args[i], true
+			continue
+		}
+		if strings.HasPrefix(arg, "-") && len(arg) > 1 {
+			for _, f := range arg[1:] {
+				switch f {
+				case 'i':
+					ignoreCase = true
+				case 'v':
+					invert = true
+				case 'c':
+					count = true
+				case 'n':
+					number = true
+				case 'w':
+					word = true
+				case 'E':
+				case 'F':
+					fixed = true
+				default:
+					return nil, fmt.Errorf("grep: 不支持的参数 -%c", f)
+				}
+			}
+			continue
+		}
+		if hasPattern {
+			return nil, fmt.Errorf("grep: 不支持读取文件 %s", arg)
+		}
+		pattern, hasPattern = arg, true
+	}
+	if !hasPattern {
+		return nil, fmt.Errorf("grep: 缺少匹配模式")
+	}
+
+	if fixed {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if word {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("grep: 无效的匹配模式: %v", err)
+	}
+
+	return func(input string) (string, error) {
+		var matched []string
+		for i, line := range splitLines(input) {
+			if re.MatchString(line) == invert {
+				continue
+			}
+			if number {
+				line = fmt.Sprintf("%d:%s", i+1, line)
+			}
+			matched = append(matched, line)
+		}
+		if count {
+			return fmt.Sprintf("%d\n", len(matched)), nil
+		}
+		return joinLines(matched), nil
+	}, nil
+}
+
+// newWc 支持 wc 的 -l -w -c 参数
+func newWc(args []string) (filter, error) {
+	var lines, words, bytes bool
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return nil, fmt.Errorf("wc: 不支持读取文件 %s", arg)
+		}
+		for _, f := range arg[1:] {
+			switch f {
+			case 'l':
+				lines = true
+			case 'w':
+				words = true
+			case 'c', 'm':
+				bytes = true
+			default:
+				return nil, fmt.Errorf("wc: 不支持的参数 -%c", f)
+			}
+		}
+	}
+	if !lines && !words && !bytes {
+		lines, words, bytes = true, true, true
+	}
+
+	return func(input string) (string, error) {
+		var counts []string
+		if lines {
+			counts = append(counts, strconv.Itoa(strings.Count(input, "\n")))
+		}
+		if words {
+			counts = append(counts, strconv.Itoa(len(strings.Fields(input))))
+		}
+		if bytes {
+			counts = append(counts, strconv.Itoa(len(input)))
+		}
+		return strings.Join(counts, " ") + "\n", nil
+	}, nil
+}
+
+// newHeadTail 支持 head/tail 的 -n N 与 -N 参数
+func newHeadTail(args []string, tail bool) (filter, error) {
+	n := 10
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		value := ""
+		switch {
+		case arg == "-n" && i+1 < len(args):
+			i++
+			value = args[i]
+		case strings.HasPrefix(arg, "-n"):
+			value = arg[2:]
+		case strings.HasPrefix(arg, "-"):
+			value = arg[1:]
+		default:
+			return nil, fmt.Errorf("不支持读取文件 %s", arg)
+		}
+		v, err := strconv.Atoi(value)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("无效的行数 %q", value)
+		}
+		n = v
+	}
+
+	return func(input string) (string, error) {
+		lines := splitLines(input)
+		if len(lines) <= n {
+			return joinLines(lines), nil
+		}
+		if tail {
+			return joinLines(lines[len(lines)-n:]), nil
+		}
+		return joinLines(lines[:n]), nil
+	}, nil
+}
+
+// newSort 支持 sort 的 -r -n -u -k N 参数，字段按空白分隔
+func newSort(args []string) (filter, error) {
+	var reverse, numeric, unique bool
+	key := 0
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-k" && i+1 < len(args) {
+			i++
+			arg = "-k" + args[i]
+		}
+		if strings.HasPrefix(arg, "-k") {
+			// 只按单个字段排序，-k2 与 -k2,2 等价
+			field := strings.SplitN(arg[2:], ",", 2)[0]
+			field = strings.TrimRight(field, "nr")
+			v, err := strconv.Atoi(field)
+			if err != nil || v <= 0 {
+				return nil, fmt.Errorf("sort: 无效的字段 %q", arg[2:])
+			}
+			key = v
+			numeric = numeric || strings.Contains(arg[2:], "n")
+			reverse = reverse || strings.Contains(arg[2:], "r")
+			continue
+		}
+		if !strings.HasPrefix(arg, "-") {
+			return nil, fmt.Errorf("sort: 不支持读取文件 %s", arg)
+		}
+		for _, f := range arg[1:] {
+			switch f {
+			case 'r':
+				reverse = true
+			case 'n', 'h':
+				numeric = true
+			case 'u':
+				unique = true
+			default:
+				return nil, fmt.Errorf("sort: 不支持的参数 -%c", f)
+			}
+		}
+	}
+
+	sortKey := func(line string) string {
+		if key == 0 {
+			return line
+		}
+		fields := strings.Fields(line)
+		if key > len(fields) {
+			return ""
+		}
+		return fields[key-1]
+	}
+	less := func(a, b string) bool {
+		ka, kb := sortKey(a), sortKey(b)
+		if numeric {
+			na, _ := strconv.ParseFloat(strings.Fields(ka + " 0")[0], 64)
+			nb, _ := strconv.ParseFloat(strings.Fields(kb + " 0")[0], 64)
+			if na != nb {
+				return na < nb
+			}
+		}
+		return ka < kb
+	}
+
+	return func(input string) (string, error) {
+		lines := splitLines(input)
+		sort.SliceStable(lines, func(i, j int) bool {
+			if reverse {
+				return less(lines[j], lines[i])
+			}
+			return less(lines[i], lines[j])
+		})
+		if unique {
+			var deduped []string
+			for i, line := range lines {
+				if i == 0 || sortKey(line) != sortKey(lines[i-1]) {
+					deduped = append(deduped, line)
+				}
+			}
+			lines = deduped
+		}
+		return joinLines(lines), nil
+	}, nil
+}
+
+// newUniq 支持 uniq 的 -c 参数
+func newUniq(args []string) (filter, error) {
+	count := false
+	for _, arg := range args {
+		if arg != "-c" {
+			return nil, fmt.Errorf("uniq: 不支持的参数 %s", arg)
+		}
+		count = true
+	}
+
+	return func(input string) (string, error) {
+		var result []string
+		lines := splitLines(input)
+		for i := 0; i < len(lines); {
+			j := i
+			for j < len(lines) && lines[j] == lines[i] {
+				j++
+			}
+			if count {
+				result = append(result, fmt.Sprintf("%7d %s", j-i, lines[i]))
+			} else {
+				result = append(result, lines[i])
+			}
+			i = j
+		}
+		return joinLines(result), nil
+	}, nil
+}
+
+// newCut 支持 cut 的 -d 与 -f 参数
+func newCut(args []string) (filter, error) {
+	delim := "\t"
+	var fields []int
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		value := ""
+		if (arg == "-d" || arg == "-f") && i+1 < len(args) {
+			i++
+			value = args[i]
+		} else if len(arg) > 2 && (strings.HasPrefix(arg, "-d") || strings.HasPrefix(arg, "-f")) {
+			value = arg[2:]
+		} else {
+			return nil, fmt.Errorf("cut: 不支持的参数 %s", arg)
+		}
+
+		if arg[:2] == "-d" {
+			delim = value
+			continue
+		}
+		for _, f := range strings.Split(value, ",") {
+			v, err := strconv.Atoi(f)
+			if err != nil || v <= 0 {
+				return nil, fmt.Errorf("cut: 无效的字段 %q", f)
+			}
+			fields = append(fields, v)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("cut: 缺少 -f 参数")
+	}
+
+	return func(input string) (string, error) {
+		var result []string
+		for _, line := range splitLines(input) {
+			parts := strings.Split(line, delim)
+			var picked []string
+			for _, f := range fields {
+				if f <= len(parts) {
+					picked = append(picked, parts[f-1])
+				}
+			}
+			result = append(result, strings.Join(picked, delim))
+		}
+		return joinLines(result), nil
+	}, nil
+}
+
+// awkProgram 匹配 awk 的子集：可选的 /正则/ 或 NR>N 条件加 {print 字段列表}
+var awkProgram = regexp.MustCompile(`^\s*(?:/((?:[^/\\]|\\.)*)/|NR\s*>\s*(\d+))?\s*\{\s*print\s*([^}]*)\}\s*$`)
+
+// awkField 匹配 awk 的字段引用 $N
+var awkField = regexp.MustCompile(`^\$\d+$`)
+
+// newAwk 支持 awk '{print $1, $NF}' 形式的字段提取，以及 -F 分隔符
+func newAwk(args []string) (filter, error) {
+	sep := ""
+	var program string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-F" && i+1 < len(args):
+			i++
+			sep = args[i]
+		case strings.HasPrefix(arg, "-F"):
+			sep = arg[2:]
+		case program == "":
+			program = arg
+		default:
+			return nil, fmt.Errorf("awk: 不支持读取文件 %s", arg)
+		}
+	}
+
+	m := awkProgram.FindStringSubmatch(program)
+	if m == nil {
+		return nil, fmt.Errorf("awk: 仅支持 '{print $N}' 形式的程序: %s", program)
+	}
+	var cond *regexp.Regexp
+	if m[1] != "" {
+		var err error
+		if cond, err = regexp.Compile(m[1]); err != nil {
+			return nil, fmt.Errorf("awk: 无效的正则: %v", err)
+		}
+	}
+	skip := 0
+	if m[2] != "" {
+		skip, _ = strconv.Atoi(m[2])
+	}
+
+	var exprs []string
+	for _, e := range strings.FieldsFunc(m[3], func(r rune) bool { return r == ',' || r == ' ' }) {
+		if e != "$NF" && !awkField.MatchString(e) {
+			return nil, fmt.Errorf("awk: 不支持的表达式 %s", e)
+		}
+		exprs = append(exprs, e)
+	}
+	if len(exprs) == 0 {
+		exprs = []string{"$0"}
+	}
+
+	return func(input string) (string, error) {
+		var result []string
+		for n, line := range splitLines(input) {
+			if n < skip || (cond != nil && !cond.MatchString(line)) {
+				continue
+			}
+			var fields []string
+			if sep == "" {
+				fields = strings.Fields(line)
+			} else {
+				fields = strings.Split(line, sep)
+			}
+			var out []string
+			for _, e := range exprs {
+				switch e {
+				case "$NF":
+					if len(fields) > 0 {
+						out = append(out, fields[len(fields)-1])
+					}
+				default:
+					idx, _ := strconv.Atoi(e[1:])
+					if idx == 0 {
+						out = append(out, line)
+					} else if idx <= len(fields) {
+						out = append(out, fields[idx-1])
+					} else {
+						out = append(out, "")
+					}
+				}
+			}
+			result = append(result, strings.Join(out, " "))
+		}
+		return joinLines(result), nil
+	}, nil
+}
@@ -0,0 +1,108 @@
+package kubectl
+
+import "testing"
+
+// podList 是 kubectl get pods 的表格输出
+const podList = `NAME    READY   STATUS             RESTARTS   AGE
+web-1   1/1     Running            0          5d
+api-2   0/1     CrashLoopBackOff   12         2h
+web-3   1/1     Running            3          1d
+db-0    1/1     Running            100        30d
+`
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		stage    []string
+		input    string
+		want     string
+		buildErr bool
+		runErr   bool
+	}{
+		// grep
+		{name: "grep pattern", stage: []string{"grep", "web"}, input: podList,
+			want: "web-1   1/1     Running            0          5d\nweb-3   1/1     Running            3          1d\n"},
+		{name: "grep ignore case and invert", stage: []string{"grep", "-iv", "running"}, input: podList,
+			want: "NAME    READY   STATUS             RESTARTS   AGE\napi-2   0/1     CrashLoopBackOff   12         2h\n"},
+		{name: "grep count", stage: []string{"grep", "-c", "Running"}, input: podList, want: "3\n"},
+		{name: "grep line numbers", stage: []string{"grep", "-n", "api"}, input: podList,
+			want: "3:api-2   0/1     CrashLoopBackOff   12         2h\n"},
+		{name: "grep word", stage: []string{"grep", "-w", "db"}, input: "db-0\ndbx\n", want: "db-0\n"},
+		{name: "grep fixed string", stage: []string{"grep", "-F", "1/1."}, input: "1/1.\n1/10\n", want: "1/1.\n"},
+		{name: "grep extended regexp", stage: []string{"grep", "-E", "api|db"}, input: "api\nweb\ndb\n", want: "api\ndb\n"},
+		{name: "grep -e pattern", stage: []string{"grep", "-e", "-1"}, input: "web-1\nweb-2\n", want: "web-1\n"},
+		{name: "grep no match", stage: []string{"grep", "missing"}, input: podList, want: ""},
+		{name: "grep missing pattern", stage: []string{"grep", "-i"}, buildErr: true},
+		{name: "grep reads file", stage: []string{"grep", "web", "/etc/passwd"}, buildErr: true},
+		{name: "grep unsupported flag", stage: []string{"grep", "-r", "web"}, buildErr: true},
+		{name: "grep invalid regexp", stage: []string{"grep", "("}, buildErr: true},
+
+		// sort
+		{name: "sort lines", stage: []string{"sort"}, input: "b\nc\na\n", want: "a\nb\nc\n"},
+		{name: "sort reverse", stage: []string{"sort", "-r"}, input: "b\nc\na\n", want: "c\nb\na\n"},
+		{name: "sort numeric key", stage: []string{"sort", "-k4", "-n"}, input: "a x x 12\nb x x 3\nc x x 100\n",
+			want: "b x x 3\na x x 12\nc x x 100\n"},
+		{name: "sort key with modifiers", stage: []string{"sort", "-k", "2,2nr"}, input: "a 1\nb 10\nc 2\n",
+			want: "b 10\nc 2\na 1\n"},
+		{name: "sort lexical key", stage: []string{"sort", "-k2"}, input: "a 1\nb 10\nc 2\n",
+			want: "a 1\nb 10\nc 2\n"},
+		{name: "sort unique", stage: []string{"sort", "-u"}, input: "b\na\nb\n", want: "a\nb\n"},
+		{name: "sort invalid key", stage: []string{"sort", "-k0"}, buildErr: true},
+
+		// awk
+		{name: "awk print fields", stage: []string{"awk", "{print $1, $3}"}, input: podList,
+			want: "NAME STATUS\nweb-1 Running\napi-2 CrashLoopBackOff\nweb-3 Running\ndb-0 Running\n"},
+		{name: "awk skip header", stage: []string{"awk", "NR>1 {print $1}"}, input: podList,
+			want: "web-1\napi-2\nweb-3\ndb-0\n"},
+		{name: "awk regexp condition", stage: []string{"awk", "/CrashLoop/ {print $1}"}, input: podList, want: "api-2\n"},
+		{name: "awk last field and separator", stage: []string{"awk", "-F:", "{print $NF}"}, input: "a:b:c\nd:e\n",
+			want: "c\ne\n"},
+		{name: "awk whole line", stage: []string{"awk", "{print}"}, input: "a  b\n", want: "a  b\n"},
+		{name: "awk missing field", stage: []string{"awk", "{print $9}"}, input: "a b\n", want: "\n"},
+		{name: "awk unsupported program", stage: []string{"awk", "{sum += $4} END {print sum}"}, buildErr: true},
+		{name: "awk unsupported expression", stage: []string{"awk", "{print $1 + 1}"}, buildErr: true},
+
+		// jq
+		{name: "jq path", stage: []string{"jq", "-r", ".metadata.name"}, input: `{"metadata":{"name":"web"}}`, want: "web\n"},
+		{name: "jq iterate", stage: []string{"jq", "-r", ".items[].metadata.name"},
+			input: `{"items":[{"metadata":{"name":"a"}},{"metadata":{"name":"b"}}]}`, want: "a\nb\n"},
+		{name: "jq index and compact", stage: []string{"jq", "-c", ".items[1]"}, input: `{"items":[{"n":1},{"n":2}]}`,
+			want: "{\"n\":2}\n"},
+		{name: "jq select", stage: []string{"jq", "-r", `.items[] | select(.status.phase != "Running") | .metadata.name`},
+			input: `{"items":[{"metadata":{"name":"a"},"status":{"phase":"Running"}},{"metadata":{"name":"b"},"status":{"phase":"Pending"}}]}`,
+			want:  "b\n"},
+		{name: "jq length", stage: []string{"jq", ".items | length"}, input: `{"items":[1,2,3]}`, want: "3\n"},
+		{name: "jq keys", stage: []string{"jq", "-c", ".data | keys"}, input: `{"data":{"b":"1","a":"2"}}`, want: "[\"a\",\"b\"]\n"},
+		{name: "jq quoted key", stage: []string{"jq", "-r", `.["app.kubernetes.io/name"]`}, input: `{"app.kubernetes.io/name":"web"}`,
+			want: "web\n"},
+		{name: "jq identity", stage: []string{"jq"}, input: `{"a":1}`, want: "{\n  \"a\": 1\n}\n"},
+		{name: "jq invalid input", stage: []string{"jq", "."}, input: "not json", runErr: true},
+		{name: "jq unsupported flag", stage: []string{"jq", "-s", "."}, buildErr: true},
+
+		// 其他过滤器
+		{name: "wc lines", stage: []string{"wc", "-l"}, input: podList, want: "5\n"},
+		{name: "head", stage: []string{"head", "-n", "2"}, input: "a\nb\nc\n", want: "a\nb\n"},
+		{name: "tail", stage: []string{"tail", "-1"}, input: "a\nb\nc\n", want: "c\n"},
+		{name: "uniq count", stage: []string{"uniq", "-c"}, input: "a\na\nb\n", want: "      2 a\n      1 b\n"},
+		{name: "cut fields", stage: []string{"cut", "-d", ":", "-f", "1,3"}, input: "a:b:c\n", want: "a:c\n"},
+		{name: "command not in whitelist", stage: []string{"sh", "-c", "id"}, buildErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newFilter(tt.stage)
+			if (err != nil) != tt.buildErr {
+				t.Fatalf("newFilter() error = %v, wantErr %v", err, tt.buildErr)
+			}
+			if err != nil {
+				return
+			}
+			got, err := f(tt.input)
+			if (err != nil) != tt.runErr {
+				t.Fatalf("filter() error = %v, wantErr %v", err, tt.runErr)
+			}
+			if got != tt.want {
+				t.Errorf("filter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,548 @@
+package kubectl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/kubectl-ai/pkg/audit"
+	"github.com/yourusername/kubectl-ai/pkg/config"
+	"github.com/yourusername/kubectl-ai/pkg/utils"
+)
+
+// ErrNoSnapshot 表示撤销日志中的记录没有可恢复的快照
+var ErrNoSnapshot = errors.New("没有可恢复的快照")
+
+// JournalEntry 是撤销日志中的一条记录，保存写操作执行前目标对象的完整 YAML
+type JournalEntry struct {
+	ID        int       `json:"id"`
+	Time      time.Time `json:"time"`
+	Context   string    `json:"context"`
+	Namespace string    `json:"namespace"`
+	Command   string    `json:"command"`
+	Snapshot  string    `json:"snapshot"`
+	// Absent 是执行前不存在、由该命令创建的对象，撤销时删除
+	Absent []ObjectRef `json:"absent,omitempty"`
+	// UndoOf 非零时表示该记录是对指定记录的撤销
+	UndoOf int `json:"undo_of,omitempty"`
+}
+
+// Undoable 判断记录是否可以撤销：带有快照或记录了由该命令创建的对象
+func (e *JournalEntry) Undoable() bool {
+	return e.Snapshot != "" || len(e.Absent) > 0
+}
+
+// ObjectRef 标识一个 Kubernetes 对象，Namespace 为空时使用记录的命名空间
+type ObjectRef struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// String 返回 kind/namespace/name 形式名称
+func (r ObjectRef) String() string {
+	return objectName(r.object(""))
+}
+
+// object 返回只包含标识字段的对象，用于 kubectl get 与 kubectl delete -f -；
+// 对象未指定命名空间时使用 namespace
+func (r ObjectRef) object(namespace string) map[string]interface{} {
+	metadata := map[string]interface{}{"name": r.Name}
+	if r.Namespace != "" {
+		namespace = r.Namespace
+	}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	return map[string]interface{}{"apiVersion": r.APIVersion, "kind": r.Kind, "metadata": metadata}
+}
+
+// objectRef 提取对象的标识字段
+func objectRef(obj map[string]interface{}) ObjectRef {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	ref := ObjectRef{}
+	ref.APIVersion, _ = obj["apiVersion"].(string)
+	ref.Kind, _ = obj["kind"].(string)
+	ref.Namespace, _ = metadata["namespace"].(string)
+	ref.Name, _ = metadata["name"].(string)
+	return ref
+}
+
+// Journal 是以 JSON Lines 格式保存在本地的撤销日志
+type Journal struct {
+	path string
+}
+
+// NewJournal 创建保存在 dir 目录下的撤销日志
+func NewJournal(dir string) *Journal {
+	return &Journal{path: filepath.Join(dir, "journal.jsonl")}
+}
+
+// journalMu 保证同一进程中并发执行的命令追加记录时分配的 ID 不重复
+var journalMu sync.Mutex
+
+// Append 追加一条记录并分配递增的 ID
+func (j *Journal) Append(entry *JournalEntry) error {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	entries, err := j.List()
+	if err != nil {
+		return err
+	}
+	entry.ID = 1
+	if len(entries) > 0 {
+		entry.ID = entries[len(entries)-1].ID + 1
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("序列化撤销日志失败: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0700); err != nil {
+		return fmt.Errorf("创建撤销日志目录失败: %v", err)
+	}
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("打开撤销日志失败: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入撤销日志失败: %v", err)
+	}
+	return nil
+}
+
+// List 按时间顺序返回全部记录
+func (j *Journal) List() ([]JournalEntry, error) {
+	f, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开撤销日志失败: %v", err)
+	}
+	defer f.Close()
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("解析撤销日志失败: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取撤销日志失败: %v", err)
+	}
+	return entries, nil
+}
+
+// Get 返回指定 ID 的记录；id 为 0 时返回最近一条尚未撤销且可以撤销的记录
+func (j *Journal) Get(id int) (*JournalEntry, error) {
+	entries, err := j.List()
+	if err != nil {
+		return nil, err
+	}
+
+	if id != 0 {
+		for i := range entries {
+			if entries[i].ID == id {
+				return &entries[i], nil
+			}
+		}
+		return nil, fmt.Errorf("撤销日志中不存在记录 #%d", id)
+	}
+
+	undone := map[int]bool{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := &entries[i]
+		if entry.UndoOf != 0 {
+			undone[entry.UndoOf] = true
+			continue
+		}
+		if !undone[entry.ID] && entry.Undoable() {
+			return entry, nil
+		}
+	}
+	return nil, fmt.Errorf("撤销日志中没有可撤销的记录")
+}
+
+// snapshotVerbs 是执行前需要保存目标对象快照的写操作动词。
+// drain 只保存节点的快照，撤销时只能恢复节点的调度状态，不能恢复被驱逐的 Pod
+var snapshotVerbs = map[string]bool{
+	"delete": true, "patch": true, "replace": true, "apply": true, "scale": true,
+	"label": true, "annotate": true, "set": true, "edit": true, "taint": true,
+	"rollout": true, "drain": true, "cordon": true, "uncordon": true,
+}
+
+// nodeVerbs 是以节点名称为位置参数的动词
+var nodeVerbs = map[string]bool{
+	"drain": true, "cordon": true, "uncordon": true,
+}
+
+// snapshotFlags 是用于定位目标对象、在 kubectl get 中同样适用的参数
+var snapshotFlags = map[string]bool{
+	"-l": true, "--selector": true, "-f": true, "--filename": true, "-k": true,
+	"--kustomize": true, "-R": true, "--recursive": true, "--field-selector": true,
+	"-A": true, "--all-namespaces": true,
+}
+
+// snapshotArgs 根据写操作命令构造获取其目标对象的 kubectl get 参数，无法确定目标时返回 nil
+func snapshotArgs(a Assessment, argv []string) []string {
+	if !snapshotVerbs[a.Verb] {
+		return nil
+	}
+
+	// 收集动词（及子命令）之后的位置参数，去掉 key=value、key- 形式的标签、环境变量和污点
+	var subjects []string
+	skip := 1
+	if a.Verb == "set" || a.Verb == "rollout" {
+		skip = 2
+	}
+	for i := 1; i < len(argv); i++ {
+		arg := argv[i]
+		if arg == "--" {
+			break
+		}
+		if strings.HasPrefix(arg, "-") {
+			name, _, hasValue := strings.Cut(arg, "=")
+			if !hasValue && takesValue(a.Verb, name) {
+				i++
+			}
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		if strings.Contains(arg, "=") || strings.HasSuffix(arg, "-") || strings.Contains(arg, ":") {
+			continue
+		}
+		subjects = append(subjects, arg)
+	}
+	if nodeVerbs[a.Verb] && len(subjects) > 0 {
+		subjects = append([]string{"nodes"}, subjects...)
+	}
+
+	flags := pickFlags(argv, snapshotFlags)
+	if len(subjects) == 0 && len(flags) == 0 {
+		return nil
+	}
+	args := append([]string{"get"}, subjects...)
+	args = append(args, flags...)
+	args = append(args, pickFlags(argv, targetFlags)...)
+	return append(args, "-o", "yaml")
+}
+
+// manifestFlags 是 kubectl create 中指定对象文件的参数
+var manifestFlags = map[string]bool{
+	"-f": true, "--filename": true, "-k": true, "--kustomize": true, "-R": true, "--recursive": true,
+}
+
+// snapshot 在写操作执行前获取目标对象的 YAML；基于文件的 apply 返回执行前已存在对象的 YAML 与尚不存在的对象
+func (e *Executor) snapshot(ctx context.Context, a Assessment, argv []string, target Target) (string, []ObjectRef, error) {
+	if a.Verb == "apply" && hasFlag(argv, "-f", "--filename", "-k", "--kustomize") {
+		return e.snapshotManifest(ctx, argv, target)
+	}
+
+	args := snapshotArgs(a, argv)
+	if args == nil {
+		return "", nil, nil
+	}
+	args = pinContext(args, target)
+	out, err := exec.CommandContext(ctx, "kubectl", args...).Output()
+	if err != nil {
+		return "", nil, commandStderr(err)
+	}
+	objects, err := decodeObjects(out)
+	if err != nil || len(objects) == 0 {
+		return "", nil, err
+	}
+	return string(out), nil, nil
+}
+
+// snapshotManifest 逐个获取文件中声明的对象：已存在的对象保存当前状态，不存在的对象记录为将由该命令创建
+func (e *Executor) snapshotManifest(ctx context.Context, argv []string, target Target) (string, []ObjectRef, error) {
+	args := append([]string{"create", "--dry-run=client", "-o", "yaml"}, pickFlags(argv, manifestFlags)...)
+	args = pinContext(append(args, pickFlags(argv, targetFlags)...), target)
+	out, err := exec.CommandContext(ctx, "kubectl", args...).Output()
+	if err != nil {
+		return "", nil, commandStderr(err)
+	}
+	objects, err := decodeObjects(out)
+	if err != nil {
+		return "", nil, err
+	}
+	liveArgs := pinContext(pickFlags(argv, targetFlags), target)
+
+	var existing []string
+	var absent []ObjectRef
+	for _, obj := range objects {
+		ref := objectRef(obj)
+		if ref.Kind == "" || ref.Name == "" {
+			continue
+		}
+		live, err := e.liveObject(ctx, liveArgs, ref.object(target.Namespace))
+		if err != nil {
+			return "", nil, err
+		}
+		if live == "" {
+			absent = append(absent, ref)
+		} else {
+			existing = append(existing, live)
+		}
+	}
+	return strings.Join(existing, "---\n"), absent, nil
+}
+
+// pinContext 在参数未指定 --context 时追加命令解析得到的 context，
+// 使快照取自命令实际作用的集群，而不是获取快照时的当前 context
+func pinContext(args []string, target Target) []string {
+	if target.Context == "" || hasFlag(args, "--context") {
+		return args
+	}
+	return append(args, "--context", target.Context)
+}
+
+// record 保存写操作执行前的快照，target 为执行命令时解析得到的目标，失败时只显示警告，不影响命令执行
+func (e *Executor) record(ctx context.Context, command Command, a Assessment, target Target, argv []string) {
+	if e.journal == nil || !snapshotVerbs[a.Verb] {
+		return
+	}
+
+	snapshot, absent, err := e.snapshot(ctx, a, argv, target)
+	if err != nil {
+		fmt.Fprintf(e.out, "%s获取快照失败，此命令将无法撤销：%v\n", utils.Yellow("[撤销] "), err)
+	}
+
+	entry := &JournalEntry{
+		Time:      time.Now(),
+		Context:   target.Context,
+		Namespace: target.Namespace,
+		Command:   command.Cmd,
+		Snapshot:  snapshot,
+		Absent:    absent,
+	}
+	if err := e.journal.Append(entry); err != nil {
+		fmt.Fprintf(e.out, "%s%v\n", utils.Yellow("[撤销] "), err)
+		return
+	}
+	if entry.Undoable() {
+		fmt.Fprintf(e.out, "%s已保存快照 #%d，可使用 kubectl ai undo %d 恢复\n", utils.Blue("[撤销] "), entry.ID, entry.ID)
+		if len(absent) > 0 {
+			fmt.Fprintf(e.out, "%s其中 %d 个对象执行前不存在，撤销时将被删除\n", utils.Blue("[撤销] "), len(absent))
+		}
+		if a.Verb == "drain" {
+			fmt.Fprintf(e.out, "%sdrain 只能部分撤销：撤销只恢复节点的调度状态，不会恢复被驱逐的 Pod\n", utils.Yellow("[撤销] "))
+		}
+	}
+}
+
+// restoreStep 是撤销时对单个对象执行的恢复操作：create、replace 或 delete
+type restoreStep struct {
+	verb      string
+	object    map[string]interface{}
+	namespace string
+}
+
+// argv 返回与恢复操作等价的 kubectl 参数，用于策略匹配
+func (s restoreStep) argv(kubeContext string) []string {
+	ref := objectRef(s.object)
+	argv := []string{"kubectl", s.verb, kindResource(ref.Kind) + "/" + ref.Name}
+	if s.namespace != "" {
+		argv = append(argv, "-n", s.namespace)
+	}
+	return append(argv, "--context", kubeContext)
+}
+
+// kindResource 将对象的 kind 转换为策略规则中使用的复数资源名
+func kindResource(kind string) string {
+	resource := normalizeResource(kind)
+	switch {
+	case resource != strings.ToLower(kind) || strings.HasSuffix(resource, "s"):
+		return resource
+	case strings.HasSuffix(resource, "y"):
+		return strings.TrimSuffix(resource, "y") + "ies"
+	default:
+		return resource + "s"
+	}
+}
+
+// Undo 将撤销日志中 id 对应记录的快照恢复到集群，id 为 0 时恢复最近一条记录；
+// 已存在的对象使用 kubectl replace 覆盖，已删除的对象使用 kubectl create 重建，由该命令创建的对象被删除。
+// 恢复操作与普通命令一样经过策略、受保护目标与 dry-run 检查，并写入审计日志
+func (e *Executor) Undo(ctx context.Context, id int) error {
+	start := time.Now()
+	result := &Result{Command: fmt.Sprintf("kubectl ai undo %d", id), Type: CommandDangerous}
+	err := e.undo(ctx, id, result)
+	e.report(ctx, start, result, err)
+	return err
+}
+
+// undo 执行撤销，并将风险等级、目标与确认结果填入执行结果
+func (e *Executor) undo(ctx context.Context, id int, result *Result) error {
+	if e.journal == nil {
+		return fmt.Errorf("未配置撤销日志目录")
+	}
+	entry, err := e.journal.Get(id)
+	if err != nil {
+		return err
+	}
+	result.Command = fmt.Sprintf("kubectl ai undo %d", entry.ID)
+	result.Context, result.Namespace = entry.Context, entry.Namespace
+	if !entry.Undoable() {
+		return fmt.Errorf("%w: #%d %s", ErrNoSnapshot, entry.ID, entry.Command)
+	}
+	if entry.Context == "" {
+		return fmt.Errorf("#%d 未记录执行时的 kube-context，无法确定恢复的目标集群", entry.ID)
+	}
+	objects, err := decodeObjects([]byte(entry.Snapshot))
+	if err != nil {
+		return err
+	}
+
+	// 恢复前保存当前状态，使撤销本身也可以被撤销，同时确定每个对象的恢复方式
+	contextArgs := []string{"--context", entry.Context}
+	var current []string
+	var created []ObjectRef
+	var steps []restoreStep
+	for _, obj := range objects {
+		live, err := e.liveObject(ctx, contextArgs, obj)
+		if err != nil {
+			return err
+		}
+		verb := "replace"
+		if live != "" {
+			current = append(current, live)
+		} else {
+			verb = "create"
+			created = append(created, objectRef(obj))
+		}
+		cleanObject(obj)
+		steps = append(steps, restoreStep{verb: verb, object: obj, namespace: firstNonEmpty(objectRef(obj).Namespace, entry.Namespace)})
+	}
+	for _, ref := range entry.Absent {
+		obj := ref.object(entry.Namespace)
+		live, err := e.liveObject(ctx, contextArgs, obj)
+		if err != nil {
+			return err
+		}
+		if live != "" {
+			current = append(current, live)
+		}
+		steps = append(steps, restoreStep{verb: "delete", object: obj, namespace: firstNonEmpty(ref.Namespace, entry.Namespace)})
+	}
+
+	// 按顺序匹配策略规则：任一操作命中 deny 时拒绝，命中 confirm 时总是确认，全部命中 allow 时跳过确认
+	level := RiskLevelWrite
+	protected, confirmRule, allowed := false, false, len(steps) > 0
+	for _, step := range steps {
+		a := Assessment{Level: RiskLevelWrite, Verb: step.verb, Namespace: step.namespace, Context: entry.Context,
+			Resources: []string{kindResource(objectRef(step.object).Kind)}}
+		if step.verb == "delete" {
+			a.Level = RiskLevelDestructive
+		}
+		level = max(level, a.Level)
+		target := Target{Context: entry.Context, Namespace: step.namespace}
+		protected = protected || e.isProtected(target, false)
+
+		decision := e.policy.Evaluate(a, step.argv(entry.Context), target)
+		switch decision.Action() {
+		case config.PolicyDeny:
+			result.Risk = level.String()
+			result.Decision = audit.DecisionDenied
+			return fmt.Errorf("%w: %s 命中%s", ErrPolicyDenied, result.Command, decision)
+		case config.PolicyConfirm:
+			confirmRule = true
+		}
+		allowed = allowed && decision.Action() == config.PolicyAllow
+	}
+	result.Risk = level.String()
+	needConfirm := confirmRule || (!e.autoExecute && !allowed)
+
+	fmt.Fprintf(e.out, "\n%s即将恢复 #%d（%s）执行前的 %d 个对象\n", utils.Yellow("[撤销] "), entry.ID,
+		entry.Time.Format("2006-01-02 15:04:05"), len(objects))
+	for _, ref := range entry.Absent {
+		fmt.Fprintf(e.out, "  删除该命令创建的 %s\n", ref)
+	}
+	fmt.Fprintf(e.out, "  命令: %s\n", entry.Command)
+	fmt.Fprintf(e.out, "  目标: context=%s namespace=%s\n", displayOrNone(entry.Context), displayOrNone(entry.Namespace))
+
+	// dry-run 模式下只显示恢复计划，不修改集群
+	if e.dryRun {
+		fmt.Fprintf(e.out, "%s未执行：%s\n", utils.Yellow("[dry-run] "), result.Command)
+		result.Decision = audit.DecisionDryRun
+		result.ExitCode = -1
+		return nil
+	}
+
+	// 受保护的 context 或命名空间忽略 auto_execute 和 allow 规则，总是要求输入 context 名称
+	result.Decision = audit.DecisionCancelled
+	switch {
+	case protected:
+		promptMu.Lock()
+		confirmed := e.confirmTyped(entry.Context)
+		promptMu.Unlock()
+		if !confirmed {
+			return ErrCancelled
+		}
+		result.Decision = audit.DecisionConfirmed
+	case needConfirm:
+		if !e.Confirm("是否确认恢复？") {
+			return ErrCancelled
+		}
+		result.Decision = audit.DecisionConfirmed
+	default:
+		result.Decision = audit.DecisionNotRequired
+	}
+
+	for _, step := range steps {
+		args := append([]string{step.verb, "-f", "-"}, contextArgs...)
+		if step.verb == "delete" {
+			args = append(args, "--ignore-not-found")
+		}
+		cmd := exec.CommandContext(ctx, "kubectl", args...)
+		cmd.Stdin = strings.NewReader(encodeObject(step.object))
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return &CommandError{Command: "kubectl " + strings.Join(args, " "), Output: string(output), Err: err}
+		}
+		fmt.Fprint(e.out, string(output))
+	}
+
+	return e.journal.Append(&JournalEntry{
+		Time:      time.Now(),
+		Context:   entry.Context,
+		Namespace: entry.Namespace,
+		Command:   result.Command,
+		Snapshot:  strings.Join(current, "---\n"),
+		Absent:    created,
+		UndoOf:    entry.ID,
+	})
+}
+
+// cleanObject 去掉由服务端维护、重建对象时不能携带的字段
+func cleanObject(obj map[string]interface{}) {
+	delete(obj, "status")
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"uid", "resourceVersion", "generation", "creationTimestamp",
+			"deletionTimestamp", "deletionGracePeriodSeconds", "managedFields", "selfLink"} {
+			delete(metadata, field)
+		}
+	}
+}
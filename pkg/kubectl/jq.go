@@ -0,0 +1,322 @@
+package kubectl
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jqStep 是 jq 表达式中的一步，对每个输入值产生零个或多个输出值
+type jqStep func(v interface{}) ([]interface{}, error)
+
+// newJq 支持 jq 的一个子集：路径访问（.a.b、.items[]、.items[0]、.["key"]）、
+// 管道、length、keys 以及 select(路径 ==/!= 字面量)，参数支持 -r 与 -c
+func newJq(args []string) (filter, error) {
+	var raw, compact bool
+	var program string
+	hasProgram := false
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") && len(arg) > 1 {
+			for _, f := range arg[1:] {
+				switch f {
+				case 'r':
+					raw = true
+				case 'c':
+					compact = true
+				default:
+					return nil, fmt.Errorf("jq: 不支持的参数 -%c", f)
+				}
+			}
+			continue
+		}
+		if hasProgram {
+			return nil, fmt.Errorf("jq: 不支持读取文件 %s", arg)
+		}
+		program, hasProgram = arg, true
+	}
+	if !hasProgram {
+		program = "."
+	}
+
+	var steps []jqStep
+	for _, part := range splitTopLevel(program, '|') {
+		step, err := parseJqTerm(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("jq: %v", err)
+		}
+		steps = append(steps, step)
+	}
+
+	return func(input string) (string, error) {
+		var values []interface{}
+		decoder := json.NewDecoder(strings.NewReader(input))
+		for decoder.More() {
+			var v interface{}
+			if err := decoder.Decode(&v); err != nil {
+				return "", fmt.Errorf("输入不是合法的 JSON: %v", err)
+			}
+			values = append(values, v)
+		}
+
+		for _, step := range steps {
+			var next []interface{}
+			for _, v := range values {
+				out, err := step(v)
+				if err != nil {
+					return "", err
+				}
+				next = append(next, out...)
+			}
+			values = next
+		}
+
+		var sb strings.Builder
+		for _, v := range values {
+			if s, ok := v.(string); ok && raw {
+				sb.WriteString(s)
+			} else {
+				var data []byte
+				var err error
+				if compact {
+					data, err = json.Marshal(v)
+				} else {
+					data, err = json.MarshalIndent(v, "", "  ")
+				}
+				if err != nil {
+					return "", err
+				}
+				sb.Write(data)
+			}
+			sb.WriteString("\n")
+		}
+		return sb.String(), nil
+	}, nil
+}
+
+// splitTopLevel 按不在括号、方括号和字符串内的分隔符拆分表达式
+func splitTopLevel(s string, sep rune) []string {
+	var parts []string
+	depth := 0
+	inString := false
+	start := 0
+	for i, r := range s {
+		switch {
+		case inString:
+			if r == '"' && (i == 0 || s[i-1] != '\\') {
+				inString = false
+			}
+		case r == '"':
+			inString = true
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			depth--
+		case r == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseJqTerm 解析管道中的单个表达式
+func parseJqTerm(term string) (jqStep, error) {
+	switch {
+	case term == "length":
+		return func(v interface{}) ([]interface{}, error) {
+			switch t := v.(type) {
+			case []interface{}:
+				return []interface{}{float64(len(t))}, nil
+			case map[string]interface{}:
+				return []interface{}{float64(len(t))}, nil
+			case string:
+				return []interface{}{float64(len([]rune(t)))}, nil
+			case nil:
+				return []interface{}{float64(0)}, nil
+			}
+			return nil, fmt.Errorf("jq: 无法计算 %T 的长度", v)
+		}, nil
+
+	case term == "keys":
+		return func(v interface{}) ([]interface{}, error) {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("jq: 只能对对象使用 keys")
+			}
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			out := make([]interface{}, len(keys))
+			for i, k := range keys {
+				out[i] = k
+			}
+			return []interface{}{out}, nil
+		}, nil
+
+	case strings.HasPrefix(term, "select(") && strings.HasSuffix(term, ")"):
+		return parseJqSelect(term[len("select(") : len(term)-1])
+	}
+
+	return parseJqPath(term)
+}
+
+// parseJqSelect 解析 select(路径 ==/!= 字面量)
+func parseJqSelect(cond string) (jqStep, error) {
+	op := "=="
+	idx := strings.Index(cond, "==")
+	if i := strings.Index(cond, "!="); i >= 0 && (idx < 0 || i < idx) {
+		op, idx = "!=", i
+	}
+	if idx < 0 {
+		return nil, fmt.Errorf("select 仅支持 == 与 != 比较: %s", cond)
+	}
+
+	path, err := parseJqPath(strings.TrimSpace(cond[:idx]))
+	if err != nil {
+		return nil, err
+	}
+	var literal interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(cond[idx+2:])), &literal); err != nil {
+		return nil, fmt.Errorf("select 的比较值必须是 JSON 字面量: %s", cond[idx+2:])
+	}
+
+	return func(v interface{}) ([]interface{}, error) {
+		values, err := path(v)
+		if err != nil {
+			return nil, err
+		}
+		for _, got := range values {
+			if (fmt.Sprint(got) == fmt.Sprint(literal)) == (op == "==") {
+				return []interface{}{v}, nil
+			}
+		}
+		return nil, nil
+	}, nil
+}
+
+// parseJqPath 解析路径表达式，如 .、.items[].metadata.name、.["app.kubernetes.io/name"]
+func parseJqPath(path string) (jqStep, error) {
+	if !strings.HasPrefix(path, ".") {
+		return nil, fmt.Errorf("不支持的表达式: %s", path)
+	}
+
+	var steps []jqStep
+	rest := path[1:]
+	for rest != "" {
+		switch {
+		case rest[0] == '.':
+			rest = rest[1:]
+
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("方括号未闭合: %s", path)
+			}
+			index := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			step, err := jqIndexStep(index)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			rest = rest[end:]
+			steps = append(steps, jqFieldStep(key))
+		}
+	}
+
+	return func(v interface{}) ([]interface{}, error) {
+		values := []interface{}{v}
+		for _, step := range steps {
+			var next []interface{}
+			for _, value := range values {
+				out, err := step(value)
+				if err != nil {
+					return nil, err
+				}
+				next = append(next, out...)
+			}
+			values = next
+		}
+		return values, nil
+	}, nil
+}
+
+// jqIndexStep 处理 []、[N] 与 ["key"]
+func jqIndexStep(index string) (jqStep, error) {
+	if index == "" {
+		return func(v interface{}) ([]interface{}, error) {
+			switch t := v.(type) {
+			case []interface{}:
+				return t, nil
+			case map[string]interface{}:
+				keys := make([]string, 0, len(t))
+				for k := range t {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				out := make([]interface{}, len(keys))
+				for i, k := range keys {
+					out[i] = t[k]
+				}
+				return out, nil
+			case nil:
+				return nil, nil
+			}
+			return nil, fmt.Errorf("jq: 无法遍历 %T", v)
+		}, nil
+	}
+
+	if strings.HasPrefix(index, `"`) {
+		key, err := strconv.Unquote(index)
+		if err != nil {
+			return nil, fmt.Errorf("无效的键: %s", index)
+		}
+		return jqFieldStep(key), nil
+	}
+
+	n, err := strconv.Atoi(index)
+	if err != nil {
+		return nil, fmt.Errorf("无效的下标: %s", index)
+	}
+	return func(v interface{}) ([]interface{}, error) {
+		arr, ok := v.([]interface{})
+		if !ok {
+			if v == nil {
+				return []interface{}{nil}, nil
+			}
+			return nil, fmt.Errorf("jq: 无法对 %T 使用下标", v)
+		}
+		i := n
+		if i < 0 {
+			i += len(arr)
+		}
+		if i < 0 || i >= len(arr) {
+			return []interface{}{nil}, nil
+		}
+		return []interface{}{arr[i]}, nil
+	}, nil
+}
+
+// jqFieldStep 处理 .key
+func jqFieldStep(key string) jqStep {
+	return func(v interface{}) ([]interface{}, error) {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			return []interface{}{t[key]}, nil
+		case nil:
+			return []interface{}{nil}, nil
+		}
+		return nil, fmt.Errorf("jq: 无法在 %T 上访问字段 %s", v, key)
+	}
+}
@@ -0,0 +1,67 @@
+package kubectl
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+)
+
+// Target 表示命令实际作用的 kube-context 与命名空间
+type Target struct {
+	Context   string
+	Namespace string
+}
+
+// ResolveTarget 根据命令参数和当前 kubeconfig 确定命令作用的 kube-context 与命名空间
+func (e *Executor) ResolveTarget(ctx context.Context, a Assessment) Target {
+	t := Target{Context: a.Context, Namespace: a.Namespace}
+	if t.Context == "" {
+		t.Context = e.currentContext(ctx)
+	}
+	if t.Namespace == "" && !a.AllNamespaces {
+		t.Namespace = e.contextNamespace(ctx, t.Context)
+	}
+	return t
+}
+
+// currentContext 返回 kubeconfig 中的当前 kube-context，查询失败时返回空字符串且不缓存
+func (e *Executor) currentContext(ctx context.Context) string {
+	if e.kubeContext == nil {
+		out, err := exec.CommandContext(ctx, "kubectl", "config", "current-context").Output()
+		current := strings.TrimSpace(string(out))
+		if err != nil || current == "" {
+			return ""
+		}
+		e.kubeContext = &current
+	}
+	return *e.kubeContext
+}
+
+// contextNamespace 返回指定 kube-context 的默认命名空间，未设置时为 default，查询失败时返回空字符串且不缓存
+func (e *Executor) contextNamespace(ctx context.Context, kubeContext string) string {
+	if ns, ok := e.namespaces[kubeContext]; ok {
+		return ns
+	}
+
+	args := []string{"config", "view", "--minify", "-o", "jsonpath={..namespace}"}
+	if kubeContext != "" {
+		args = append(args, "--context", kubeContext)
+	}
+	out, err := exec.CommandContext(ctx, "kubectl", args...).Output()
+	if err != nil {
+		return ""
+	}
+	ns := strings.TrimSpace(string(out))
+	if ns == "" {
+		ns = "default"
+	}
+	e.namespaces[kubeContext] = ns
+	return ns
+}
+
+// forgetTarget 清除当前 context 与默认命名空间的缓存。缓存只在一条命令内有效，
+// 命令（如 kubectl config use-context）或其他进程切换 context 后，下一条命令重新解析
+func (e *Executor) forgetTarget() {
+	e.kubeContext = nil
+	e.namespaces = make(map[string]string)
+}
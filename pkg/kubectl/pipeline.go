@@ -0,0 +1,193 @@
+package kubectl
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// splitPipeline 将命令行按未加引号的 | 拆分为多个阶段，第一个阶段必须是 kubectl，
+// 其余阶段只允许白名单中的只读过滤器或 xargs kubectl
+func splitPipeline(command string) ([][]string, error) {
+	tokens, err := tokenize(command)
+	if err != nil {
+		return nil, err
+	}
+
+	var stages [][]string
+	var current []string
+	for _, t := range tokens {
+		if !t.op {
+			current = append(current, t.text)
+			continue
+		}
+		if t.text != "|" {
+			return nil, fmt.Errorf("不支持的 shell 操作符 %q: %s", t.text, command)
+		}
+		stages = append(stages, current)
+		current = nil
+	}
+	stages = append(stages, current)
+
+	return stages, validatePipeline(stages)
+}
+
+// splitArgvPipeline 将结构化响应中的参数列表按单独的 "|" 元素拆分为多个阶段
+func splitArgvPipeline(argv []string) ([][]string, error) {
+	var stages [][]string
+	var current []string
+	for _, arg := range argv {
+		if arg == "|" {
+			stages = append(stages, current)
+			current = nil
+			continue
+		}
+		current = append(current, arg)
+	}
+	stages = append(stages, current)
+
+	return stages, validatePipeline(stages)
+}
+
+// validatePipeline 检查管道的每个阶段是否允许执行
+func validatePipeline(stages [][]string) error {
+	if err := validateArgv(stages[0]); err != nil {
+		return err
+	}
+	for _, stage := range stages[1:] {
+		if len(stage) == 0 {
+			return fmt.Errorf("管道中存在空命令")
+		}
+		if stage[0] == "xargs" {
+			if _, err := parseXargs(stage); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := newFilter(stage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runPipeline 执行 kubectl 命令并依次通过后续阶段处理标准输出，返回处理后的标准输出与 kubectl 的标准错误。
+// 与 shell 一致，标准错误不经过后续阶段；两者都记录到 result 中
+func (e *Executor) runPipeline(ctx context.Context, command Command, stages [][]string, result *Result) (string, error) {
+	stdout, stderr, err := e.runKubectl(ctx, command.Cmd, stages[0])
+	result.Stdout, result.Stderr = stdout, stderr
+	if err != nil {
+		return stdout + stderr, err
+	}
+
+	for _, stage := range stages[1:] {
+		if stage[0] == "xargs" {
+			if stdout, err = e.runXargs(ctx, command, stage, stdout); err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		filter, err := newFilter(stage)
+		if err != nil {
+			return "", err
+		}
+		if stdout, err = filter(stdout); err != nil {
+			return "", fmt.Errorf("%s: %v", stage[0], err)
+		}
+	}
+	result.Stdout = stdout
+	return stdout + stderr, nil
+}
+
+// xargsSpec 描述 xargs 阶段：将输入拼接到 kubectl 命令的参数中
+type xargsSpec struct {
+	// replace 对应 -I，非空时每行输入执行一次并替换参数中的占位符
+	replace string
+	// maxArgs 对应 -n，每次执行最多追加的输入参数个数，0 表示全部追加
+	maxArgs int
+	argv    []string
+}
+
+// parseXargs 解析 xargs 阶段，只允许 xargs 调用 kubectl
+func parseXargs(stage []string) (*xargsSpec, error) {
+	spec := &xargsSpec{}
+	i := 1
+	for ; i < len(stage) && strings.HasPrefix(stage[i], "-"); i++ {
+		switch {
+		case stage[i] == "-I" && i+1 < len(stage):
+			i++
+			spec.replace = stage[i]
+		case strings.HasPrefix(stage[i], "-I") && len(stage[i]) > 2:
+			spec.replace = stage[i][2:]
+		case stage[i] == "-n" && i+1 < len(stage):
+			i++
+			n, err := strconv.Atoi(stage[i])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("xargs: 无效的 -n 参数 %q", stage[i])
+			}
+			spec.maxArgs = n
+		case stage[i] == "-r" || stage[i] == "--no-run-if-empty":
+			// 本实现在输入为空时总是不执行
+		default:
+			return nil, fmt.Errorf("xargs: 不支持的参数 %q", stage[i])
+		}
+	}
+
+	spec.argv = stage[i:]
+	if len(spec.argv) == 0 || spec.argv[0] != "kubectl" {
+		return nil, fmt.Errorf("xargs 只允许调用 kubectl: %s", QuoteArgv(stage))
+	}
+	return spec, nil
+}
+
+// runXargs 根据输入构造 kubectl 命令，每条命令都像直接生成的命令一样经过 Run 的确认流程
+func (e *Executor) runXargs(ctx context.Context, parent Command, stage []string, input string) (string, error) {
+	spec, err := parseXargs(stage)
+	if err != nil {
+		return "", err
+	}
+
+	var invocations [][]string
+	if spec.replace != "" {
+		for _, line := range strings.Split(input, "\n") {
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+			argv := make([]string, len(spec.argv))
+			for i, arg := range spec.argv {
+				argv[i] = strings.ReplaceAll(arg, spec.replace, line)
+			}
+			invocations = append(invocations, argv)
+		}
+	} else {
+		words := strings.Fields(input)
+		batch := spec.maxArgs
+		if batch == 0 {
+			batch = len(words)
+		}
+		for start := 0; start < len(words); start += batch {
+			end := start + batch
+			if end > len(words) {
+				end = len(words)
+			}
+			argv := append(append([]string{}, spec.argv...), words[start:end]...)
+			invocations = append(invocations, argv)
+		}
+	}
+
+	var sb strings.Builder
+	for _, argv := range invocations {
+		child := Command{Type: CommandNormal, Cmd: QuoteArgv(argv), Argv: argv}
+		if parent.Type == CommandDangerous {
+			child.Type = CommandDangerous
+		}
+		output, err := e.Run(ctx, child)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(output)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,202 @@
+package kubectl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/yourusername/kubectl-ai/pkg/config"
+)
+
+// ErrPolicyDenied 表示命令被策略规则拒绝
+var ErrPolicyDenied = errors.New("命令被策略拒绝")
+
+// Policy 是按顺序匹配的命令策略，第一条匹配的规则生效
+type Policy struct {
+	rules []compiledRule
+}
+
+// compiledRule 是预编译正则后的策略规则
+type compiledRule struct {
+	config.PolicyRule
+	args []*regexp.Regexp
+}
+
+// PolicyDecision 是策略的匹配结果，Rule 为空表示没有规则匹配
+type PolicyDecision struct {
+	Rule  *config.PolicyRule
+	Index int
+}
+
+// Action 返回匹配规则的动作，没有规则匹配时返回空字符串
+func (d PolicyDecision) Action() string {
+	if d.Rule == nil {
+		return ""
+	}
+	return d.Rule.Action
+}
+
+// String 返回匹配结果的描述
+func (d PolicyDecision) String() string {
+	if d.Rule == nil {
+		return "无匹配规则，按风险等级处理"
+	}
+	name := d.Rule.Name
+	if name == "" {
+		name = fmt.Sprintf("#%d", d.Index+1)
+	}
+	return fmt.Sprintf("规则 %s（第 %d 条）：%s", name, d.Index+1, d.Rule.Action)
+}
+
+// NewPolicy 校验并编译策略规则
+func NewPolicy(rules []config.PolicyRule) (*Policy, error) {
+	p := &Policy{}
+	for i, rule := range rules {
+		switch rule.Action {
+		case config.PolicyAllow, config.PolicyDeny, config.PolicyConfirm:
+		default:
+			return nil, fmt.Errorf("policy rule %d: invalid action %q, must be allow, deny or confirm", i+1, rule.Action)
+		}
+
+		compiled := compiledRule{PolicyRule: rule}
+		compiled.Resources = make([]string, len(rule.Resources))
+		for j, r := range rule.Resources {
+			compiled.Resources[j] = normalizeResource(r)
+		}
+		for _, pattern := range rule.Args {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("policy rule %d: invalid args pattern %q: %v", i+1, pattern, err)
+			}
+			compiled.args = append(compiled.args, re)
+		}
+		for _, glob := range append(append([]string{}, rule.Namespaces...), rule.Contexts...) {
+			if _, err := path.Match(glob, ""); err != nil {
+				return nil, fmt.Errorf("policy rule %d: invalid pattern %q: %v", i+1, glob, err)
+			}
+		}
+		p.rules = append(p.rules, compiled)
+	}
+	return p, nil
+}
+
+// Evaluate 返回第一条与命令匹配的规则
+func (p *Policy) Evaluate(a Assessment, argv []string, target Target) PolicyDecision {
+	commandLine := QuoteArgv(argv)
+	for i := range p.rules {
+		rule := &p.rules[i]
+		if !matchAny(rule.Verbs, a.Verb, equalOrWildcard) ||
+			!rule.matchResources(a.Resources) ||
+			!rule.matchNamespace(target, a.AllNamespaces) ||
+			!rule.matchContext(target.Context) {
+			continue
+		}
+		if len(rule.args) > 0 {
+			matched := false
+			for _, re := range rule.args {
+				if re.MatchString(commandLine) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+		}
+		return PolicyDecision{Rule: &rule.PolicyRule, Index: i}
+	}
+	return PolicyDecision{Index: -1}
+}
+
+// matchResources 判断规则的资源类型是否匹配命令涉及的资源：deny 与 confirm 规则匹配其中任意一项，
+// allow 规则要求每一项都匹配，避免 pods,secrets 借助只放行 pods 的规则绕过限制
+func (r *compiledRule) matchResources(resources []string) bool {
+	if len(resources) == 0 {
+		return matchAny(r.Resources, "", equalOrWildcard)
+	}
+	allow := r.Action == config.PolicyAllow
+	for _, resource := range resources {
+		matched := matchAny(r.Resources, resource, equalOrWildcard)
+		if matched && !allow {
+			return true
+		}
+		if !matched && allow {
+			return false
+		}
+	}
+	return allow
+}
+
+// matchNamespace 判断规则的命名空间是否匹配目标。跨所有命名空间或无法确定命名空间的命令可能作用于任意命名空间，
+// 与 isProtected 一样视为命中 deny 与 confirm 规则中的命名空间；allow 规则只有包含 * 时才匹配
+func (r *compiledRule) matchNamespace(target Target, allNamespaces bool) bool {
+	if (!allNamespaces && target.Namespace != "") || len(r.Namespaces) == 0 {
+		return matchAny(r.Namespaces, target.Namespace, globMatch)
+	}
+	if r.Action != config.PolicyAllow {
+		return true
+	}
+	return slices.Contains(r.Namespaces, "*")
+}
+
+// matchContext 判断规则的 kube-context 是否匹配目标。无法确定 context 时命令可能作用于任意集群，
+// 视为命中 deny 与 confirm 规则；allow 规则只有包含 * 时才匹配
+func (r *compiledRule) matchContext(kubeContext string) bool {
+	if kubeContext != "" || len(r.Contexts) == 0 {
+		return matchAny(r.Contexts, kubeContext, globMatch)
+	}
+	if r.Action != config.PolicyAllow {
+		return true
+	}
+	return slices.Contains(r.Contexts, "*")
+}
+
+// NeedsTarget 判断策略是否需要知道命令的 kube-context 或命名空间
+func (p *Policy) NeedsTarget() bool {
+	for _, rule := range p.rules {
+		if len(rule.Namespaces) > 0 || len(rule.Contexts) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAny 判断 value 是否匹配 patterns 中的任意一个，patterns 为空时总是匹配
+func matchAny(patterns []string, value string, match func(pattern, value string) bool) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if match(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalOrWildcard 精确匹配，* 匹配任意值
+func equalOrWildcard(pattern, value string) bool {
+	return pattern == "*" || strings.EqualFold(pattern, value)
+}
+
+// globMatch 按 glob 通配符匹配
+func globMatch(pattern, value string) bool {
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
+
+// CheckCommand 对命令行进行风险评估和策略匹配，用于 policy test 子命令
+func (e *Executor) CheckCommand(ctx context.Context, command string) (Assessment, Target, PolicyDecision, error) {
+	stages, err := splitPipeline(command)
+	if err != nil {
+		return Assessment{}, Target{}, PolicyDecision{}, err
+	}
+	_, stages = e.applyScope(Command{Cmd: command}, stages)
+	assessment := Classify(stages[0])
+	target := e.ResolveTarget(ctx, assessment)
+	return assessment, target, e.policy.Evaluate(assessment, stages[0], target), nil
+}
@@ -0,0 +1,181 @@
+package kubectl
+
+import (
+	"testing"
+
+	"github.com/yourusername/kubectl-ai/pkg/config"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	rules := []config.PolicyRule{
+		{Name: "deny-prod-delete", Action: config.PolicyDeny, Verbs: []string{"delete"}, Namespaces: []string{"prod"}},
+		{Name: "deny-prod-context", Action: config.PolicyDeny, Verbs: []string{"drain"}, Contexts: []string{"prod-*"}},
+		{Name: "confirm-secrets", Action: config.PolicyConfirm, Resources: []string{"secret"}},
+		{Name: "allow-dev-pods", Action: config.PolicyAllow, Verbs: []string{"delete"}, Resources: []string{"po"}, Namespaces: []string{"dev-*"}},
+		{Name: "allow-scale-anywhere", Action: config.PolicyAllow, Verbs: []string{"scale"}, Namespaces: []string{"*"}},
+		{Name: "deny-force", Action: config.PolicyDeny, Args: []string{`--force\b`}},
+	}
+	policy, err := NewPolicy(rules)
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		argv   []string
+		target Target
+		want   string
+	}{
+		{
+			name:   "deny in namespace",
+			argv:   []string{"kubectl", "delete", "pod", "web", "-n", "prod"},
+			target: Target{Context: "dev", Namespace: "prod"},
+			want:   "deny-prod-delete",
+		},
+		{
+			name:   "all namespaces matches namespaced deny",
+			argv:   []string{"kubectl", "delete", "pods", "--all", "-A"},
+			target: Target{Context: "dev"},
+			want:   "deny-prod-delete",
+		},
+		{
+			name:   "long all namespaces flag matches namespaced deny",
+			argv:   []string{"kubectl", "delete", "pods", "--all", "--all-namespaces"},
+			target: Target{Context: "dev"},
+			want:   "deny-prod-delete",
+		},
+		{
+			name:   "context glob",
+			argv:   []string{"kubectl", "drain", "node-1"},
+			target: Target{Context: "prod-east", Namespace: "default"},
+			want:   "deny-prod-context",
+		},
+		{
+			name:   "unknown context matches context deny",
+			argv:   []string{"kubectl", "drain", "node-1"},
+			target: Target{Namespace: "default"},
+			want:   "deny-prod-context",
+		},
+		{
+			name:   "unknown namespace matches namespaced deny",
+			argv:   []string{"kubectl", "delete", "pod", "web"},
+			target: Target{Context: "dev"},
+			want:   "deny-prod-delete",
+		},
+		{
+			name:   "context glob does not match other contexts",
+			argv:   []string{"kubectl", "drain", "node-1"},
+			target: Target{Context: "staging", Namespace: "default"},
+		},
+		{
+			name:   "resource alias",
+			argv:   []string{"kubectl", "get", "secrets", "-o", "yaml"},
+			target: Target{Context: "dev", Namespace: "default"},
+			want:   "confirm-secrets",
+		},
+		{
+			name:   "any listed resource matches confirm",
+			argv:   []string{"kubectl", "get", "cm,secret", "-o", "yaml"},
+			target: Target{Context: "dev", Namespace: "default"},
+			want:   "confirm-secrets",
+		},
+		{
+			name:   "allow in matching namespace",
+			argv:   []string{"kubectl", "delete", "pod", "web", "-n", "dev-a"},
+			target: Target{Context: "dev", Namespace: "dev-a"},
+			want:   "allow-dev-pods",
+		},
+		{
+			name:   "allow requires every listed resource",
+			argv:   []string{"kubectl", "delete", "pods,deploy", "web", "-n", "dev-a"},
+			target: Target{Context: "dev", Namespace: "dev-a"},
+		},
+		{
+			name:   "namespaced allow does not match all namespaces",
+			argv:   []string{"kubectl", "delete", "pods", "--all", "-A"},
+			target: Target{Context: "staging"},
+			want:   "deny-prod-delete",
+		},
+		{
+			name:   "wildcard allow matches all namespaces",
+			argv:   []string{"kubectl", "scale", "deploy", "--all", "--replicas=1", "-A"},
+			target: Target{Context: "dev"},
+			want:   "allow-scale-anywhere",
+		},
+		{
+			name:   "args pattern",
+			argv:   []string{"kubectl", "replace", "--force", "-f", "app.yaml"},
+			target: Target{Context: "dev", Namespace: "default"},
+			want:   "deny-force",
+		},
+		{
+			name:   "no match",
+			argv:   []string{"kubectl", "get", "pods"},
+			target: Target{Context: "dev", Namespace: "default"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := policy.Evaluate(Classify(tt.argv), tt.argv, tt.target)
+			got := ""
+			if decision.Rule != nil {
+				got = decision.Rule.Name
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate() matched %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPolicyRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule config.PolicyRule
+	}{
+		{name: "unknown action", rule: config.PolicyRule{Action: "block"}},
+		{name: "invalid args regexp", rule: config.PolicyRule{Action: config.PolicyDeny, Args: []string{"("}}},
+		{name: "invalid namespace glob", rule: config.PolicyRule{Action: config.PolicyDeny, Namespaces: []string{"prod-["}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPolicy([]config.PolicyRule{tt.rule}); err == nil {
+				t.Error("NewPolicy() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestIsProtected(t *testing.T) {
+	e := &Executor{
+		protectedContexts:   []string{"prod-*", "*-production"},
+		protectedNamespaces: []string{"kube-*", "payments"},
+	}
+	tests := []struct {
+		name          string
+		target        Target
+		allNamespaces bool
+		want          bool
+	}{
+		{name: "context prefix glob", target: Target{Context: "prod-east", Namespace: "default"}, want: true},
+		{name: "context suffix glob", target: Target{Context: "eu-production", Namespace: "default"}, want: true},
+		{name: "namespace glob", target: Target{Context: "dev", Namespace: "kube-system"}, want: true},
+		{name: "exact namespace", target: Target{Context: "dev", Namespace: "payments"}, want: true},
+		{name: "unprotected", target: Target{Context: "dev", Namespace: "default"}, want: false},
+		{name: "glob does not match substring", target: Target{Context: "preprod-east", Namespace: "default"}, want: false},
+		{name: "all namespaces", target: Target{Context: "dev"}, allNamespaces: true, want: true},
+		{name: "unknown context", target: Target{Namespace: "default"}, want: true},
+		{name: "unknown namespace", target: Target{Context: "dev"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := e.isProtected(tt.target, tt.allNamespaces); got != tt.want {
+				t.Errorf("isProtected() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if (&Executor{}).isProtected(Target{Context: "prod-east"}, true) {
+		t.Error("isProtected() = true without protected targets, want false")
+	}
+}
@@ -0,0 +1,268 @@
+package kubectl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/yourusername/kubectl-ai/pkg/utils"
+)
+
+// dryRunVerbs 是支持 --dry-run=server 的写操作动词
+var dryRunVerbs = map[string]bool{
+	"apply": true, "create": true, "replace": true, "patch": true, "scale": true,
+	"autoscale": true, "label": true, "annotate": true, "set": true, "expose": true,
+	"taint": true,
+}
+
+// targetFlags 是决定命令作用的集群、用户与命名空间的参数，预览时需要原样保留
+var targetFlags = map[string]bool{
+	"-n": true, "--namespace": true, "--context": true, "--cluster": true, "--user": true,
+	"--kubeconfig": true, "-s": true, "--server": true, "--token": true, "--as": true,
+	"--as-group": true,
+}
+
+// diffFlags 是 kubectl diff 支持的文件来源参数
+var diffFlags = map[string]bool{
+	"-f": true, "--filename": true, "-k": true, "--kustomize": true,
+	"-R": true, "--recursive": true, "-l": true, "--selector": true,
+	"--server-side": true, "--field-manager": true, "--force-conflicts": true,
+}
+
+// Preview 在执行写操作前通过服务端预演显示对象的变化：
+// 基于文件的 apply/replace 使用 kubectl diff，其余命令对比 --dry-run=server 的结果与集群中的对象
+func (e *Executor) Preview(ctx context.Context, assessment Assessment, argv []string) {
+	if !dryRunVerbs[assessment.Verb] {
+		return
+	}
+
+	var diff string
+	var err error
+	if (assessment.Verb == "apply" || assessment.Verb == "replace") && hasFlag(argv, "-f", "--filename", "-k", "--kustomize") {
+		diff, err = e.kubectlDiff(ctx, argv)
+	} else {
+		diff, err = e.dryRunDiff(ctx, argv)
+	}
+
+	if err != nil {
+		fmt.Fprintf(e.out, "%s服务端预演失败：%v\n", utils.Yellow("[预览] "), err)
+		return
+	}
+	if diff == "" {
+		fmt.Fprintf(e.out, "%s服务端预演显示对象不会发生变化\n", utils.Blue("[预览] "))
+		return
+	}
+	fmt.Fprintf(e.out, "%s服务端预演的变化：\n%s", utils.Blue("[预览] "), diff)
+}
+
+// kubectlDiff 使用 kubectl diff 对比集群中的对象与文件中的对象
+func (e *Executor) kubectlDiff(ctx context.Context, argv []string) (string, error) {
+	args := append([]string{"diff"}, pickFlags(argv, targetFlags)...)
+	args = append(args, pickFlags(argv, diffFlags)...)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// kubectl diff 在存在差异时以退出码 1 退出
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return "", fmt.Errorf("%v\n%s", err, stderr.String())
+		}
+	}
+	if strings.TrimSpace(stdout.String()) == "" {
+		return "", nil
+	}
+	return utils.ColorizeDiff(stdout.String()), nil
+}
+
+// dryRunDiff 以 --dry-run=server -o yaml 执行命令，并与集群中对应对象的当前状态对比
+func (e *Executor) dryRunDiff(ctx context.Context, argv []string) (string, error) {
+	args := append(dropFlags(argv[1:], "-o", "--output"), "--dry-run=server", "-o", "yaml")
+	out, err := exec.CommandContext(ctx, "kubectl", args...).Output()
+	if err != nil {
+		return "", commandStderr(err)
+	}
+
+	proposed, err := decodeObjects(out)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, obj := range proposed {
+		live, err := e.liveObject(ctx, argv, obj)
+		if err != nil {
+			return "", err
+		}
+		name := objectName(obj)
+		sb.WriteString(utils.UnifiedDiff("live/"+name, "dry-run/"+name, live, encodeObject(obj), 3))
+	}
+	return sb.String(), nil
+}
+
+// liveObject 获取对象在集群中的当前状态，对象不存在时返回空字符串
+func (e *Executor) liveObject(ctx context.Context, argv []string, obj map[string]interface{}) (string, error) {
+	kind, _ := obj["kind"].(string)
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	if kind == "" || name == "" {
+		return "", nil
+	}
+
+	// 使用 kind.version.group 避免同名资源的歧义
+	resource := kind
+	if apiVersion, _ := obj["apiVersion"].(string); strings.Contains(apiVersion, "/") {
+		group, version, _ := strings.Cut(apiVersion, "/")
+		resource = kind + "." + version + "." + group
+	}
+
+	args := []string{"get", resource + "/" + name, "-o", "yaml", "--ignore-not-found"}
+	args = append(args, dropFlags(pickFlags(argv, targetFlags), "-n", "--namespace")...)
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	out, err := exec.CommandContext(ctx, "kubectl", args...).Output()
+	if err != nil {
+		return "", commandStderr(err)
+	}
+	if strings.TrimSpace(string(out)) == "" {
+		return "", nil
+	}
+
+	objects, err := decodeObjects(out)
+	if err != nil || len(objects) == 0 {
+		return "", err
+	}
+	return encodeObject(objects[0]), nil
+}
+
+// decodeObjects 解析 kubectl 输出的 YAML，List 类型展开为其中的对象
+func decodeObjects(data []byte) ([]map[string]interface{}, error) {
+	var objects []map[string]interface{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var obj map[string]interface{}
+		if err := decoder.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("解析预演结果失败: %v", err)
+		}
+		if obj == nil {
+			continue
+		}
+		if items, ok := obj["items"].([]interface{}); ok && strings.HasSuffix(fmt.Sprint(obj["kind"]), "List") {
+			for _, item := range items {
+				if m, ok := item.(map[string]interface{}); ok {
+					objects = append(objects, m)
+				}
+			}
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// encodeObject 去掉每次写入都会变化的元数据后输出为 YAML
+func encodeObject(obj map[string]interface{}) string {
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "managedFields")
+		delete(metadata, "resourceVersion")
+		delete(metadata, "generation")
+	}
+	out, err := yaml.Marshal(obj)
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+// objectName 返回对象的 kind/namespace/name 形式名称
+func objectName(obj map[string]interface{}) string {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	parts := []string{fmt.Sprint(obj["kind"])}
+	if ns, _ := metadata["namespace"].(string); ns != "" {
+		parts = append(parts, ns)
+	}
+	parts = append(parts, fmt.Sprint(metadata["name"]))
+	return strings.Join(parts, "/")
+}
+
+// hasFlag 判断参数列表中是否包含任意一个参数
+func hasFlag(argv []string, names ...string) bool {
+	for _, arg := range argv {
+		name, _, _ := strings.Cut(arg, "=")
+		for _, n := range names {
+			if name == n || (len(n) == 2 && strings.HasPrefix(arg, n) && !strings.HasPrefix(arg, "--")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pickFlags 从参数列表中选出指定的参数及其值
+func pickFlags(argv []string, names map[string]bool) []string {
+	var picked []string
+	for i := 0; i < len(argv); i++ {
+		arg := argv[i]
+		if arg == "--" {
+			break
+		}
+		name, _, hasValue := strings.Cut(arg, "=")
+		switch {
+		case names[name]:
+			picked = append(picked, arg)
+			if !hasValue && valueFlags[name] && i+1 < len(argv) {
+				i++
+				picked = append(picked, argv[i])
+			}
+		case len(name) > 2 && !strings.HasPrefix(name, "--") && names[name[:2]] && valueFlags[name[:2]]:
+			// 形如 -nkube-system 的短参数
+			picked = append(picked, arg)
+		}
+	}
+	return picked
+}
+
+// dropFlags 从参数列表中去掉指定的参数及其值
+func dropFlags(argv []string, names ...string) []string {
+	drop := map[string]bool{}
+	for _, n := range names {
+		drop[n] = true
+	}
+	var kept []string
+	for i := 0; i < len(argv); i++ {
+		arg := argv[i]
+		name, _, hasValue := strings.Cut(arg, "=")
+		switch {
+		case drop[name]:
+			if !hasValue && i+1 < len(argv) {
+				i++
+			}
+		case len(name) > 2 && !strings.HasPrefix(name, "--") && drop[name[:2]]:
+		default:
+			kept = append(kept, arg)
+		}
+	}
+	return kept
+}
+
+// commandStderr 从命令执行错误中提取标准错误输出
+func commandStderr(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
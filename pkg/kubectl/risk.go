@@ -0,0 +1,380 @@
+package kubectl
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/yourusername/kubectl-ai/pkg/utils"
+)
+
+// RiskLevel 是命令的风险等级，数值越大风险越高
+type RiskLevel int
+
+const (
+	// RiskLevelRead 只读查询，无需确认
+	RiskLevelRead RiskLevel = iota
+	// RiskLevelSensitiveRead 只读但可能暴露敏感数据（如 Secret 内容）或打开访问通道
+	RiskLevelSensitiveRead
+	// RiskLevelWrite 修改集群或本地 kubeconfig 状态
+	RiskLevelWrite
+	// RiskLevelDestructive 删除资源、强制操作或大范围修改
+	RiskLevelDestructive
+)
+
+// String 返回风险等级的名称
+func (l RiskLevel) String() string {
+	switch l {
+	case RiskLevelRead:
+		return "read"
+	case RiskLevelSensitiveRead:
+		return "sensitive-read"
+	case RiskLevelWrite:
+		return "write"
+	default:
+		return "destructive"
+	}
+}
+
+// Label 返回带颜色的中文标签，用于确认提示
+func (l RiskLevel) Label() string {
+	switch l {
+	case RiskLevelRead:
+		return utils.Green("[只读] ")
+	case RiskLevelSensitiveRead:
+		return utils.Blue("[敏感读取] ")
+	case RiskLevelWrite:
+		return utils.Yellow("[写操作] ")
+	default:
+		return utils.Red("[危险操作] ")
+	}
+}
+
+// Assessment 是对一条 kubectl 命令的风险评估结果
+type Assessment struct {
+	Level         RiskLevel
+	Verb          string
+	Subcommand    string
+	Resource      string
+	Namespace     string
+	AllNamespaces bool
+	// Context 是命令中 --context 指定的 kube-context
+	Context string
+	// Resources 是命令涉及的全部资源类型，pods,secrets 形式时包含每一项，Resource 为其中第一项
+	Resources []string
+	// Output 是 -o 指定的输出格式，只指定 --template 时为 go-template
+	Output string
+	// Reasons 说明风险等级高于动词默认等级的原因
+	Reasons []string
+}
+
+// valueFlags 是需要单独参数值的常见 kubectl 参数，用于区分参数值与位置参数
+var valueFlags = map[string]bool{
+	"-n": true, "--namespace": true, "--context": true, "--cluster": true, "--user": true,
+	"--kubeconfig": true, "-s": true, "--server": true, "--token": true, "--as": true,
+	"--as-group": true, "--request-timeout": true, "-l": true, "--selector": true,
+	"-o": true, "--output": true, "-f": true, "--filename": true, "-c": true,
+	"--container": true, "--field-selector": true, "--sort-by": true, "--template": true,
+	"--replicas": true, "--grace-period": true, "--timeout": true, "--type": true,
+	"-p": true, "--patch": true, "--image": true, "--port": true, "--to-revision": true,
+	"--from-literal": true, "--from-file": true, "--since": true,
+	"--tail": true, "-k": true, "--kustomize": true, "--field-manager": true,
+}
+
+// verbBoolFlags 是部分动词下与 valueFlags 同名但不需要参数值的短参数，
+// 如 logs -f 表示 --follow、logs -p 表示 --previous
+var verbBoolFlags = map[string]map[string]bool{
+	"logs": {"-f": true, "-p": true},
+}
+
+// takesValue 判断参数在指定动词下是否需要单独的参数值
+func takesValue(verb, name string) bool {
+	return valueFlags[name] && !verbBoolFlags[verb][name]
+}
+
+// readVerbs 是只读动词
+var readVerbs = map[string]bool{
+	"get": true, "describe": true, "explain": true, "logs": true, "top": true,
+	"events": true, "api-resources": true, "api-versions": true, "cluster-info": true,
+	"version": true, "diff": true, "wait": true, "completion": true, "kustomize": true,
+	"plugin": true, "options": true,
+}
+
+// sensitiveVerbs 是只读但会打开访问通道的动词
+var sensitiveVerbs = map[string]bool{
+	"port-forward": true, "proxy": true,
+}
+
+// writeVerbs 是修改状态的动词，exec/cp/attach/debug 可以在容器内执行任意操作，同样视为写操作
+var writeVerbs = map[string]bool{
+	"apply": true, "create": true, "patch": true, "replace": true, "scale": true,
+	"autoscale": true, "label": true, "annotate": true, "edit": true, "set": true,
+	"expose": true, "run": true, "taint": true, "cordon": true, "uncordon": true,
+	"exec": true, "cp": true, "attach": true, "debug": true, "certificate": true,
+}
+
+// destructiveVerbs 是删除或驱逐资源的动词
+var destructiveVerbs = map[string]bool{
+	"delete": true, "drain": true,
+}
+
+// readSubcommands 是部分动词下的只读子命令
+var readSubcommands = map[string]map[string]bool{
+	"rollout": {"status": true, "history": true},
+	"auth":    {"can-i": true, "whoami": true},
+	"config": {
+		"view": true, "get-contexts": true, "current-context": true,
+		"get-clusters": true, "get-users": true,
+	},
+}
+
+// podVerbs 是直接以 Pod 名称为位置参数的动词
+var podVerbs = map[string]bool{
+	"exec": true, "attach": true, "logs": true, "debug": true, "port-forward": true,
+}
+
+// systemNamespaces 中的写操作按危险操作处理
+var systemNamespaces = map[string]bool{
+	"kube-system": true, "kube-public": true, "kube-node-lease": true,
+}
+
+// Classify 根据动词、子命令、资源类型、参数和目标命名空间评估 kubectl 命令的风险等级
+func Classify(argv []string) Assessment {
+	var a Assessment
+	var positionals []string
+	flags := map[string]string{}
+
+	args := argv
+	if len(args) > 0 && args[0] == "kubectl" {
+		args = args[1:]
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			// -- 之后是容器内执行的命令，不再解析
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positionals = append(positionals, arg)
+			continue
+		}
+		// 第一个位置参数是动词，部分参数是否需要参数值取决于动词
+		verb := ""
+		if len(positionals) > 0 {
+			verb = positionals[0]
+		}
+		var name, value string
+		var hasValue bool
+		if !strings.HasPrefix(arg, "--") && len(arg) > 2 && takesValue(verb, arg[:2]) {
+			// 形如 -nkube-system、-oyaml、-o=yaml、-ojsonpath={.data} 的短参数，参数值本身可能包含 =
+			name, value, hasValue = arg[:2], strings.TrimPrefix(arg[2:], "="), true
+		} else {
+			name, value, hasValue = strings.Cut(arg, "=")
+		}
+		if !hasValue && takesValue(verb, name) && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		flags[name] = value
+	}
+
+	if len(positionals) > 0 {
+		a.Verb = positionals[0]
+		positionals = positionals[1:]
+	}
+	if _, ok := readSubcommands[a.Verb]; ok || a.Verb == "create" || a.Verb == "set" || a.Verb == "certificate" {
+		if len(positionals) > 0 {
+			a.Subcommand = positionals[0]
+			positionals = positionals[1:]
+		}
+	}
+	if a.Verb == "create" && a.Subcommand != "" {
+		// kubectl create secret generic ... 中子命令即资源类型
+		a.Resources = normalizeResources(a.Subcommand)
+	} else if podVerbs[a.Verb] && len(positionals) > 0 && !strings.Contains(positionals[0], "/") {
+		// kubectl exec web 中的位置参数是 Pod 名称
+		a.Resources = []string{"pods"}
+	} else if len(positionals) > 0 {
+		a.Resources = normalizeResources(positionals[0])
+	}
+	if len(a.Resources) > 0 {
+		a.Resource = a.Resources[0]
+	}
+
+	a.Namespace = firstNonEmpty(flags["-n"], flags["--namespace"])
+	a.Context = flags["--context"]
+	a.Output = firstNonEmpty(flags["-o"], flags["--output"])
+	if _, ok := flags["--template"]; ok && a.Output == "" {
+		a.Output = "go-template"
+	}
+	_, allNs := flags["-A"]
+	_, allNamespaces := flags["--all-namespaces"]
+	a.AllNamespaces = allNs || allNamespaces
+
+	// 按动词与子命令确定基础等级
+	switch {
+	case readSubcommands[a.Verb][a.Subcommand]:
+		a.Level = RiskLevelRead
+	case a.Verb == "cluster-info" && a.Resource == "dump":
+		a.Level = RiskLevelSensitiveRead
+		a.Reasons = append(a.Reasons, "cluster-info dump 会导出集群的完整状态")
+	case readVerbs[a.Verb]:
+		a.Level = RiskLevelRead
+	case sensitiveVerbs[a.Verb]:
+		a.Level = RiskLevelSensitiveRead
+		a.Reasons = append(a.Reasons, a.Verb+" 会打开到集群的访问通道")
+	case destructiveVerbs[a.Verb]:
+		a.Level = RiskLevelDestructive
+	case writeVerbs[a.Verb], a.Verb == "rollout", a.Verb == "config", a.Verb == "auth":
+		a.Level = RiskLevelWrite
+	default:
+		// 未知动词按写操作处理
+		a.Level = RiskLevelWrite
+		if a.Verb != "" {
+			a.Reasons = append(a.Reasons, "未知的动词 "+a.Verb)
+		}
+	}
+
+	// 只读命令中可能暴露敏感数据的情况
+	if a.Level == RiskLevelRead {
+		if a.exposesSecretData() {
+			a.Level = RiskLevelSensitiveRead
+			a.Reasons = append(a.Reasons, fmt.Sprintf("以 -o %s 输出 Secret 会暴露其内容", a.Output))
+		}
+		if a.Verb == "config" && a.Subcommand == "view" {
+			if _, raw := flags["--raw"]; raw {
+				a.Level = RiskLevelSensitiveRead
+				a.Reasons = append(a.Reasons, "config view --raw 会输出证书和令牌")
+			}
+		}
+	}
+
+	// 写操作的升级条件
+	if a.Level >= RiskLevelWrite {
+		// 只有 --dry-run、--dry-run=client 与 --dry-run=server 不会修改集群，
+		// kubectl 把 --dry-run=false、=0 等布尔形式的值视为 none，命令会真正执行
+		if dryRun, ok := flags["--dry-run"]; ok && (dryRun == "" || dryRun == "client" || dryRun == "server") {
+			a.Level = RiskLevelRead
+			a.Reasons = append(a.Reasons, "--dry-run 不会修改集群")
+			return a
+		}
+
+		escalate := func(reason string) {
+			a.Level = RiskLevelDestructive
+			a.Reasons = append(a.Reasons, reason)
+		}
+		if _, ok := flags["--all"]; ok {
+			escalate("--all 作用于全部资源")
+		}
+		if a.AllNamespaces {
+			escalate("作用于所有命名空间")
+		}
+		if _, ok := flags["--force"]; ok {
+			escalate("--force 强制执行")
+		}
+		if flags["--grace-period"] == "0" {
+			escalate("--grace-period=0 立即终止")
+		}
+		if _, ok := flags["--now"]; ok {
+			escalate("--now 立即终止")
+		}
+		if a.Verb == "scale" && flags["--replicas"] == "0" {
+			escalate("缩容到 0 个副本")
+		}
+		if a.Verb == "rollout" && a.Subcommand == "undo" {
+			escalate("回滚到历史版本")
+		}
+		if systemNamespaces[a.Namespace] {
+			escalate("目标为系统命名空间 " + a.Namespace)
+		}
+		for _, resource := range a.Resources {
+			if resource == "secrets" || resource == "namespaces" || resource == "nodes" ||
+				resource == "customresourcedefinitions" || strings.HasPrefix(resource, "clusterrole") {
+				if a.Level == RiskLevelWrite && a.Verb != "create" && a.Verb != "label" && a.Verb != "annotate" {
+					escalate("目标为集群关键资源 " + resource)
+				}
+			}
+		}
+	}
+
+	return a
+}
+
+// resourceAliases 将常见的资源简写规范化为复数形式
+var resourceAliases = map[string]string{
+	"po": "pods", "pod": "pods", "svc": "services", "service": "services",
+	"deploy": "deployments", "deployment": "deployments", "ns": "namespaces",
+	"namespace": "namespaces", "no": "nodes", "node": "nodes", "secret": "secrets",
+	"cm": "configmaps", "configmap": "configmaps", "sts": "statefulsets",
+	"statefulset": "statefulsets", "ds": "daemonsets", "daemonset": "daemonsets",
+	"crd": "customresourcedefinitions", "crds": "customresourcedefinitions",
+	"customresourcedefinition": "customresourcedefinitions", "pvc": "persistentvolumeclaims",
+	"pv": "persistentvolumes", "ing": "ingresses", "ingress": "ingresses",
+	"clusterrole": "clusterroles", "clusterrolebinding": "clusterrolebindings",
+}
+
+// normalizeResources 提取命令涉及的资源类型，支持 pod/name 与 pods,svc 形式，后者返回每一项
+func normalizeResources(resource string) []string {
+	resource, _, _ = strings.Cut(resource, "/")
+	var resources []string
+	for _, r := range strings.Split(resource, ",") {
+		if r != "" {
+			resources = append(resources, normalizeResource(r))
+		}
+	}
+	return resources
+}
+
+// normalizeResource 提取资源类型，支持 pod/name 与 pods,svc 形式，后者只取第一项
+func normalizeResource(resource string) string {
+	resource = strings.ToLower(resource)
+	resource, _, _ = strings.Cut(resource, "/")
+	resource, _, _ = strings.Cut(resource, ",")
+	resource, _, _ = strings.Cut(resource, ".")
+	if alias, ok := resourceAliases[resource]; ok {
+		return alias
+	}
+	return resource
+}
+
+// exposesSecretData 判断命令是否以 yaml、json、jsonpath、go-template 等会输出内容的格式读取 Secret
+func (a Assessment) exposesSecretData() bool {
+	return a.Verb == "get" && a.involves("secrets") && a.Output != "" && a.Output != "wide" && a.Output != "name"
+}
+
+// ExposesSecretData 判断命令的输出是否包含 Secret 的内容。
+// jsonpath、go-template 等格式的输出没有 kind: Secret 之类的特征，只能根据命令本身判断
+func ExposesSecretData(command string) bool {
+	stages, err := splitPipeline(command)
+	if err != nil || len(stages) == 0 {
+		return false
+	}
+	return Classify(stages[0]).exposesSecretData()
+}
+
+// involves 判断命令是否涉及指定的资源类型
+func (a Assessment) involves(resource string) bool {
+	return slices.Contains(a.Resources, resource)
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// declaredLevel 将模型声明的风险和行格式中的命令类型转换为风险等级
+func declaredLevel(command Command) RiskLevel {
+	switch {
+	case command.Type == CommandDangerous || command.Risk == RiskDangerous:
+		return RiskLevelDestructive
+	case command.Risk == RiskWrite:
+		return RiskLevelWrite
+	default:
+		return RiskLevelRead
+	}
+}
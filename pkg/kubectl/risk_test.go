@@ -0,0 +1,333 @@
+package kubectl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name      string
+		argv      []string
+		level     RiskLevel
+		verb      string
+		resources []string
+		namespace string
+	}{
+		{
+			name:      "plain get",
+			argv:      []string{"kubectl", "get", "pods", "-n", "default"},
+			level:     RiskLevelRead,
+			verb:      "get",
+			resources: []string{"pods"},
+			namespace: "default",
+		},
+		{
+			name:      "short namespace flag without space",
+			argv:      []string{"kubectl", "get", "po", "-nkube-system"},
+			level:     RiskLevelRead,
+			verb:      "get",
+			resources: []string{"pods"},
+			namespace: "kube-system",
+		},
+		{
+			name:      "short output flag with jsonpath",
+			argv:      []string{"kubectl", "get", "secret", "db", "-ojsonpath={.data.password}"},
+			level:     RiskLevelSensitiveRead,
+			verb:      "get",
+			resources: []string{"secrets"},
+		},
+		{
+			name:      "short output flag with go-template",
+			argv:      []string{"kubectl", "get", "secret", "db", "-ogo-template={{.data.password}}"},
+			level:     RiskLevelSensitiveRead,
+			verb:      "get",
+			resources: []string{"secrets"},
+		},
+		{
+			name:      "short output flag with yaml",
+			argv:      []string{"kubectl", "get", "secrets", "-oyaml"},
+			level:     RiskLevelSensitiveRead,
+			verb:      "get",
+			resources: []string{"secrets"},
+		},
+		{
+			name:      "short output flag with equals sign",
+			argv:      []string{"kubectl", "get", "secrets", "-o=json", "-n=prod"},
+			level:     RiskLevelSensitiveRead,
+			verb:      "get",
+			resources: []string{"secrets"},
+			namespace: "prod",
+		},
+		{
+			name:      "secret listing without output format",
+			argv:      []string{"kubectl", "get", "secrets"},
+			level:     RiskLevelRead,
+			verb:      "get",
+			resources: []string{"secrets"},
+		},
+		{
+			name:      "secret yaml",
+			argv:      []string{"kubectl", "get", "secret", "db", "-o", "yaml"},
+			level:     RiskLevelSensitiveRead,
+			verb:      "get",
+			resources: []string{"secrets"},
+		},
+		{
+			name:      "secret as second comma separated resource",
+			argv:      []string{"kubectl", "get", "cm,secret", "-o", "yaml"},
+			level:     RiskLevelSensitiveRead,
+			verb:      "get",
+			resources: []string{"configmaps", "secrets"},
+		},
+		{
+			name:      "secret jsonpath",
+			argv:      []string{"kubectl", "get", "secret/db", "-o", "jsonpath={.data.password}"},
+			level:     RiskLevelSensitiveRead,
+			verb:      "get",
+			resources: []string{"secrets"},
+		},
+		{
+			name:  "config view raw",
+			argv:  []string{"kubectl", "config", "view", "--raw"},
+			level: RiskLevelSensitiveRead,
+			verb:  "config",
+		},
+		{
+			name:      "port-forward",
+			argv:      []string{"kubectl", "port-forward", "svc/web", "8080:80"},
+			level:     RiskLevelSensitiveRead,
+			verb:      "port-forward",
+			resources: []string{"services"},
+		},
+		{
+			name:      "logs follow keeps pod name",
+			argv:      []string{"kubectl", "logs", "-f", "web", "-n", "shop"},
+			level:     RiskLevelRead,
+			verb:      "logs",
+			resources: []string{"pods"},
+			namespace: "shop",
+		},
+		{
+			name:      "logs previous keeps pod name",
+			argv:      []string{"kubectl", "logs", "-p", "web"},
+			level:     RiskLevelRead,
+			verb:      "logs",
+			resources: []string{"pods"},
+		},
+		{
+			name:      "scale",
+			argv:      []string{"kubectl", "scale", "deploy/api", "--replicas=3"},
+			level:     RiskLevelWrite,
+			verb:      "scale",
+			resources: []string{"deployments"},
+		},
+		{
+			name:      "scale to zero",
+			argv:      []string{"kubectl", "scale", "deploy/api", "--replicas", "0"},
+			level:     RiskLevelDestructive,
+			verb:      "scale",
+			resources: []string{"deployments"},
+		},
+		{
+			name:  "apply file takes a value",
+			argv:  []string{"kubectl", "apply", "-f", "app.yaml"},
+			level: RiskLevelWrite,
+			verb:  "apply",
+		},
+		{
+			name:      "delete",
+			argv:      []string{"kubectl", "delete", "pod", "web"},
+			level:     RiskLevelDestructive,
+			verb:      "delete",
+			resources: []string{"pods"},
+		},
+		{
+			name:      "rollout status is read only",
+			argv:      []string{"kubectl", "rollout", "status", "deploy/api"},
+			level:     RiskLevelRead,
+			verb:      "rollout",
+			resources: []string{"deployments"},
+		},
+		{
+			name:      "rollout undo",
+			argv:      []string{"kubectl", "rollout", "undo", "deploy/api"},
+			level:     RiskLevelDestructive,
+			verb:      "rollout",
+			resources: []string{"deployments"},
+		},
+		{
+			name:      "write in system namespace",
+			argv:      []string{"kubectl", "label", "pod", "coredns", "a=b", "-n", "kube-system"},
+			level:     RiskLevelDestructive,
+			verb:      "label",
+			resources: []string{"pods"},
+			namespace: "kube-system",
+		},
+		{
+			name:      "patch critical resource in list",
+			argv:      []string{"kubectl", "patch", "cm,secret", "x", "-p", "{}"},
+			level:     RiskLevelDestructive,
+			verb:      "patch",
+			resources: []string{"configmaps", "secrets"},
+		},
+		{
+			name:      "write across all namespaces",
+			argv:      []string{"kubectl", "annotate", "pods", "--all", "-A", "a=b"},
+			level:     RiskLevelDestructive,
+			verb:      "annotate",
+			resources: []string{"pods"},
+		},
+		{
+			name:      "create secret subcommand",
+			argv:      []string{"kubectl", "create", "secret", "generic", "db"},
+			level:     RiskLevelWrite,
+			verb:      "create",
+			resources: []string{"secrets"},
+		},
+		{
+			name:      "unknown verb",
+			argv:      []string{"kubectl", "frobnicate", "pods"},
+			level:     RiskLevelWrite,
+			verb:      "frobnicate",
+			resources: []string{"pods"},
+		},
+		{
+			name:      "bare dry-run",
+			argv:      []string{"kubectl", "delete", "ns", "prod", "--dry-run"},
+			level:     RiskLevelRead,
+			verb:      "delete",
+			resources: []string{"namespaces"},
+		},
+		{
+			name:      "client dry-run",
+			argv:      []string{"kubectl", "delete", "ns", "prod", "--dry-run=client"},
+			level:     RiskLevelRead,
+			verb:      "delete",
+			resources: []string{"namespaces"},
+		},
+		{
+			name:  "server dry-run",
+			argv:  []string{"kubectl", "apply", "-f", "app.yaml", "--dry-run=server"},
+			level: RiskLevelRead,
+			verb:  "apply",
+		},
+		{
+			name:      "dry-run none executes",
+			argv:      []string{"kubectl", "delete", "ns", "prod", "--dry-run=none"},
+			level:     RiskLevelDestructive,
+			verb:      "delete",
+			resources: []string{"namespaces"},
+		},
+		{
+			name:      "boolean false dry-run executes",
+			argv:      []string{"kubectl", "delete", "ns", "prod", "--dry-run=False"},
+			level:     RiskLevelDestructive,
+			verb:      "delete",
+			resources: []string{"namespaces"},
+		},
+		{
+			name:      "numeric false dry-run executes",
+			argv:      []string{"kubectl", "scale", "deploy/api", "--replicas=2", "--dry-run=0"},
+			level:     RiskLevelWrite,
+			verb:      "scale",
+			resources: []string{"deployments"},
+		},
+		{
+			name:      "short false dry-run executes",
+			argv:      []string{"kubectl", "delete", "pod", "web", "--dry-run=f"},
+			level:     RiskLevelDestructive,
+			verb:      "delete",
+			resources: []string{"pods"},
+		},
+		{
+			name:      "exec command after double dash is not parsed",
+			argv:      []string{"kubectl", "exec", "web", "--", "rm", "-rf", "/tmp/x"},
+			level:     RiskLevelWrite,
+			verb:      "exec",
+			resources: []string{"pods"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Classify(tt.argv)
+			if got.Level != tt.level {
+				t.Errorf("Level = %s, want %s (reasons: %v)", got.Level, tt.level, got.Reasons)
+			}
+			if got.Verb != tt.verb {
+				t.Errorf("Verb = %q, want %q", got.Verb, tt.verb)
+			}
+			if !reflect.DeepEqual(got.Resources, tt.resources) {
+				t.Errorf("Resources = %q, want %q", got.Resources, tt.resources)
+			}
+			if got.Namespace != tt.namespace {
+				t.Errorf("Namespace = %q, want %q", got.Namespace, tt.namespace)
+			}
+		})
+	}
+}
+
+func TestSnapshotArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		argv []string
+		want []string
+	}{
+		{
+			name: "named object",
+			argv: []string{"kubectl", "scale", "deploy/api", "--replicas=3", "-n", "shop"},
+			want: []string{"get", "deploy/api", "-n", "shop", "-o", "yaml"},
+		},
+		{
+			name: "labels are not subjects",
+			argv: []string{"kubectl", "label", "pod", "web", "tier=front", "old-"},
+			want: []string{"get", "pod", "web", "-o", "yaml"},
+		},
+		{
+			name: "node verbs",
+			argv: []string{"kubectl", "cordon", "node-1"},
+			want: []string{"get", "nodes", "node-1", "-o", "yaml"},
+		},
+		{
+			name: "read commands have no snapshot",
+			argv: []string{"kubectl", "get", "pods"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := snapshotArgs(Classify(tt.argv), tt.argv)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("snapshotArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExposesSecretData(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{command: "kubectl get secret db -o jsonpath='{.data.password}'", want: true},
+		{command: "kubectl get secret db -o jsonpath={.data}", want: true},
+		{command: "kubectl get secret db -o go-template='{{.data.password}}'", want: true},
+		{command: "kubectl get secret db --template='{{.data.password}}'", want: true},
+		{command: "kubectl get secret db -ojsonpath={.data.password}", want: true},
+		{command: "kubectl get secret db -ogo-template={{.data.password}}", want: true},
+		{command: "kubectl get secret db -oyaml", want: true},
+		{command: "kubectl get cm,secret -o yaml", want: true},
+		{command: "kubectl get secret db -o json | jq .data", want: true},
+		{command: "kubectl get secrets", want: false},
+		{command: "kubectl get secrets -o wide", want: false},
+		{command: "kubectl get secrets -o name", want: false},
+		{command: "kubectl get configmap app -o yaml", want: false},
+		{command: "kubectl describe secret db", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := ExposesSecretData(tt.command); got != tt.want {
+				t.Errorf("ExposesSecretData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package kubectl
+
+import (
+	"context"
+	"strings"
+)
+
+// Scope 是命令行全局参数指定的默认 kube-context 与命名空间
+type Scope struct {
+	Context   string
+	Namespace string
+}
+
+// Scope 返回命令默认使用的 context 与命名空间
+func (e *Executor) Scope() Scope {
+	return e.scope
+}
+
+// SetScope 修改命令默认使用的 context 与命名空间，用于在交互模式中切换目标
+func (e *Executor) SetScope(scope Scope) {
+	e.scope = scope
+}
+
+// DefaultTarget 返回未显式指定 context 与命名空间的命令实际作用的目标
+func (e *Executor) DefaultTarget(ctx context.Context) Target {
+	return e.ResolveTarget(ctx, Assessment{Context: e.scope.Context, Namespace: e.scope.Namespace})
+}
+
+// applyScope 在命令的 kubectl 阶段补充默认的 --context 与 --namespace，
+// 命令已显式指定 context、命名空间或使用 --all-namespaces 时保持不变
+func (e *Executor) applyScope(command Command, stages [][]string) (Command, [][]string) {
+	if e.scope.Context == "" && e.scope.Namespace == "" {
+		return command, stages
+	}
+
+	a := Classify(stages[0])
+	var extra []string
+	if e.scope.Context != "" && a.Context == "" {
+		extra = append(extra, "--context", e.scope.Context)
+	}
+	if e.scope.Namespace != "" && a.Namespace == "" && !a.AllNamespaces {
+		extra = append(extra, "--namespace", e.scope.Namespace)
+	}
+	if len(extra) == 0 {
+		return command, stages
+	}
+
+	// 全局参数放在 kubectl 之后，避免落到 exec 等命令 -- 之后的容器命令中
+	argv := append([]string{stages[0][0]}, extra...)
+	argv = append(argv, stages[0][1:]...)
+	scoped := append([][]string{argv}, stages[1:]...)
+
+	var parts []string
+	for _, stage := range scoped {
+		parts = append(parts, QuoteArgv(stage))
+	}
+	command.Cmd = strings.Join(parts, " | ")
+	if len(command.Argv) > 0 {
+		command.Argv = nil
+		for i, stage := range scoped {
+			if i > 0 {
+				command.Argv = append(command.Argv, "|")
+			}
+			command.Argv = append(command.Argv, stage...)
+		}
+	}
+	return command, scoped
+}
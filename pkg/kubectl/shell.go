@@ -0,0 +1,158 @@
+package kubectl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// token 是 shell 分词的结果，op 为 true 表示未加引号的 shell 操作符，如 | ; &
+type token struct {
+	text string
+	op   bool
+}
+
+// shellOperators 是未加引号时具有特殊含义的字符
+const shellOperators = "|;&<>`"
+
+// tokenize 按 POSIX shell 规则分词：支持单引号、双引号和反斜杠转义，
+// 未加引号的操作符单独成为一个 token，单词开头未加引号的 # 到行尾为注释
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	var word strings.Builder
+	inWord := false
+
+	flush := func() {
+		if inWord {
+			tokens = append(tokens, token{text: word.String()})
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+
+		case r == '\\':
+			// 反斜杠转义下一个字符，行尾的反斜杠表示续行
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("命令以未转义的反斜杠结尾")
+			}
+			i++
+			if runes[i] != '\n' {
+				word.WriteRune(runes[i])
+				inWord = true
+			}
+
+		case r == '\'':
+			// 单引号内的内容原样保留
+			j := i + 1
+			for j < len(runes) && runes[j] != '\'' {
+				j++
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("单引号未闭合")
+			}
+			word.WriteString(string(runes[i+1 : j]))
+			i = j
+			inWord = true
+
+		case r == '"':
+			// 双引号内只有 \ 后跟 $ ` " \ 或换行时才转义
+			i++
+			closed := false
+			for ; i < len(runes); i++ {
+				c := runes[i]
+				if c == '"' {
+					closed = true
+					break
+				}
+				if c == '\\' && i+1 < len(runes) && strings.ContainsRune("$`\"\\\n", runes[i+1]) {
+					i++
+					if runes[i] != '\n' {
+						word.WriteRune(runes[i])
+					}
+					continue
+				}
+				if c == '`' || (c == '$' && i+1 < len(runes) && runes[i+1] == '(') {
+					return nil, fmt.Errorf("不支持命令替换: %s", s)
+				}
+				word.WriteRune(c)
+			}
+			if !closed {
+				return nil, fmt.Errorf("双引号未闭合")
+			}
+			inWord = true
+
+		case r == '#' && !inWord:
+			// 单词开头未加引号的 # 开始注释，直到行尾的内容都被忽略
+			for i+1 < len(runes) && runes[i+1] != '\n' {
+				i++
+			}
+
+		case r == '$' && i+1 < len(runes) && runes[i+1] == '(':
+			return nil, fmt.Errorf("不支持命令替换: %s", s)
+
+		case strings.ContainsRune(shellOperators, r):
+			flush()
+			op := string(r)
+			// && 与 || 作为一个操作符
+			if (r == '&' || r == '|') && i+1 < len(runes) && runes[i+1] == r {
+				op += string(r)
+				i++
+			}
+			tokens = append(tokens, token{text: op, op: true})
+
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	flush()
+
+	return tokens, nil
+}
+
+// SplitShellWords 按 POSIX shell 规则将命令行拆分为参数列表，
+// 包含管道、重定向、命令替换等 shell 操作符时返回错误
+func SplitShellWords(s string) ([]string, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		if t.op {
+			return nil, fmt.Errorf("不支持的 shell 操作符 %q: %s", t.text, s)
+		}
+		args = append(args, t.text)
+	}
+	return args, nil
+}
+
+// ParseArgv 将命令行解析为 kubectl 参数列表，拒绝执行非 kubectl 命令
+func ParseArgv(command string) ([]string, error) {
+	args, err := SplitShellWords(command)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateArgv(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// validateArgv 检查参数列表是否为 kubectl 调用
+func validateArgv(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("命令为空")
+	}
+	if args[0] != "kubectl" {
+		return fmt.Errorf("只允许执行 kubectl 命令: %s", QuoteArgv(args))
+	}
+	return nil
+}
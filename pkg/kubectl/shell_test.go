@@ -0,0 +1,227 @@
+package kubectl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "plain words",
+			input: "kubectl get pods -n kube-system",
+			want:  []string{"kubectl", "get", "pods", "-n", "kube-system"},
+		},
+		{
+			name:  "extra whitespace",
+			input: "  kubectl\tget   pods \n",
+			want:  []string{"kubectl", "get", "pods"},
+		},
+		{
+			name:  "single quoted jsonpath",
+			input: "kubectl get pods -o jsonpath='{.items[*].metadata.name}'",
+			want:  []string{"kubectl", "get", "pods", "-o", "jsonpath={.items[*].metadata.name}"},
+		},
+		{
+			name:  "double quoted label selector",
+			input: `kubectl get pods -l "app in (web, api)"`,
+			want:  []string{"kubectl", "get", "pods", "-l", "app in (web, api)"},
+		},
+		{
+			name:  "field selector with spaces",
+			input: `kubectl get events --field-selector "reason=Failed Scheduling"`,
+			want:  []string{"kubectl", "get", "events", "--field-selector", "reason=Failed Scheduling"},
+		},
+		{
+			name:  "escaped space outside quotes",
+			input: `kubectl label pod web note=hello\ world`,
+			want:  []string{"kubectl", "label", "pod", "web", "note=hello world"},
+		},
+		{
+			name:  "escapes inside double quotes",
+			input: `kubectl annotate pod web note="say \"hi\" \\ \$HOME \n"`,
+			want:  []string{"kubectl", "annotate", "pod", "web", `note=say "hi" \ $HOME \n`},
+		},
+		{
+			name:  "backslash is literal inside single quotes",
+			input: `kubectl get pods -o 'go-template={{"\n"}}'`,
+			want:  []string{"kubectl", "get", "pods", "-o", `go-template={{"\n"}}`},
+		},
+		{
+			name:  "empty quoted argument",
+			input: `kubectl annotate pod web note=''`,
+			want:  []string{"kubectl", "annotate", "pod", "web", "note="},
+		},
+		{
+			name:  "adjacent quoted segments",
+			input: `kubectl get pods -l 'app'="web"`,
+			want:  []string{"kubectl", "get", "pods", "-l", "app=web"},
+		},
+		{
+			name:  "line continuation",
+			input: "kubectl get pods \\\n  -A",
+			want:  []string{"kubectl", "get", "pods", "-A"},
+		},
+		{
+			name:  "quoted pipe is literal",
+			input: `kubectl get pods -l 'a|b'`,
+			want:  []string{"kubectl", "get", "pods", "-l", "a|b"},
+		},
+		{
+			name:  "trailing comment",
+			input: `kubectl get pods # list pods; rm -rf /`,
+			want:  []string{"kubectl", "get", "pods"},
+		},
+		{
+			name:  "comment ends at newline",
+			input: "kubectl get pods # list pods\n  -A",
+			want:  []string{"kubectl", "get", "pods", "-A"},
+		},
+		{
+			name:  "hash inside a word is literal",
+			input: `kubectl annotate pod web note=a#b`,
+			want:  []string{"kubectl", "annotate", "pod", "web", "note=a#b"},
+		},
+		{
+			name:  "quoted hash is literal",
+			input: `kubectl annotate pod web '#note=x'`,
+			want:  []string{"kubectl", "annotate", "pod", "web", "#note=x"},
+		},
+		{
+			name:  "chinese label value",
+			input: `kubectl get pods -l team=运维`,
+			want:  []string{"kubectl", "get", "pods", "-l", "team=运维"},
+		},
+		{
+			name:    "unterminated single quote",
+			input:   "kubectl get pods -o jsonpath='{.items",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated double quote",
+			input:   `kubectl get pods -l "app=web`,
+			wantErr: true,
+		},
+		{
+			name:    "trailing backslash",
+			input:   `kubectl get pods \`,
+			wantErr: true,
+		},
+		{
+			name:    "pipe",
+			input:   "kubectl get pods | grep Error",
+			wantErr: true,
+		},
+		{
+			name:    "command list",
+			input:   "kubectl get pods; rm -rf /",
+			wantErr: true,
+		},
+		{
+			name:    "redirect",
+			input:   "kubectl get secret db -o yaml > /tmp/secret.yaml",
+			wantErr: true,
+		},
+		{
+			name:    "command substitution",
+			input:   "kubectl delete pod $(kubectl get pods -o name)",
+			wantErr: true,
+		},
+		{
+			name:    "backtick substitution in double quotes",
+			input:   "kubectl delete pod \"`whoami`\"",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitShellWords(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("SplitShellWords(%q) = %q, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SplitShellWords(%q) unexpected error: %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitShellWords(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArgv(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "kubectl invocation",
+			input: "kubectl get pods -A",
+			want:  []string{"kubectl", "get", "pods", "-A"},
+		},
+		{
+			name:    "first word is not kubectl",
+			input:   "get pods -A",
+			wantErr: true,
+		},
+		{
+			name:    "other binary",
+			input:   "rm -rf /",
+			wantErr: true,
+		},
+		{
+			name:    "empty command",
+			input:   "   ",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseArgv(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseArgv(%q) = %q, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseArgv(%q) unexpected error: %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseArgv(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteArgvRoundTrip(t *testing.T) {
+	tests := [][]string{
+		{"kubectl", "get", "pods"},
+		{"kubectl", "get", "pods", "-o", "jsonpath={.items[*].metadata.name}"},
+		{"kubectl", "annotate", "pod", "web", "note=it's here"},
+		{"kubectl", "annotate", "pod", "web", "note="},
+		{"kubectl", "annotate", "pod", "web", "#note=x"},
+	}
+
+	for _, argv := range tests {
+		got, err := SplitShellWords(QuoteArgv(argv))
+		if err != nil {
+			t.Fatalf("SplitShellWords(QuoteArgv(%q)) unexpected error: %v", argv, err)
+		}
+		if !reflect.DeepEqual(got, argv) {
+			t.Errorf("round trip of %q = %q", argv, got)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/kubectl-ai/pkg/config"
+)
+
+// Assistant 在 Backend 之上实现命令转换与解释，与具体厂商无关。
+// Assistant 本身不保存对话历史，多轮对话由调用方传入的 Conversation 维护，可同时服务多个对话
+type Assistant struct {
+	Backend
+	profiles map[string]Backend
+}
+
+// NewAssistant 创建基于指定 Backend 的 Provider
+func NewAssistant(backend Backend) *Assistant {
+	return &Assistant{
+		Backend:  backend,
+		profiles: make(map[string]Backend),
+	}
+}
+
+// SetProfile 为指定用途设置单独的 Backend，例如 explain 使用更大的模型
+func (a *Assistant) SetProfile(name string, backend Backend) {
+	a.profiles[name] = backend
+}
+
+// backend 返回指定用途的 Backend，未单独配置时使用默认 Backend
+func (a *Assistant) backend(profile string) Backend {
+	if b, ok := a.profiles[profile]; ok {
+		return b
+	}
+	return a.Backend
+}
+
+// translateSystemPrompt 是命令转换的系统提示词，要求模型返回结构化 JSON
+const translateSystemPrompt = `你是一个 Kubernetes 专家，专门将自然语言转换为 kubectl 命令。你需要先收集必要信息，再生成精确的执行命令。
+
+请只返回一个 JSON 对象，不要返回 markdown 代码块或任何其他文本，格式如下：
+{"commands": [{"argv": ["kubectl", "get", "pods", "-n", "default"], "risk": "read", "purpose": "查看 default 命名空间的 pod", "namespace": "default", "gather": false}]}
+
+字段说明：
+1. argv：命令的参数列表，第一个元素必须是 kubectl，每个参数单独一项，不要做 shell 转义
+2. risk：read 表示只读查询，write 表示修改集群状态，dangerous 表示删除、强制操作等危险操作
+3. purpose：用一句话说明命令的目的
+4. namespace：命令作用的命名空间，集群级资源留空
+5. gather：是否为先收集集群信息的命令
+6. 需要过滤输出时，可以在 argv 中用单独的 "|" 元素连接 grep、wc、head、tail、sort、uniq、cut、awk、jq 或 xargs kubectl，不要使用其他 shell 语法
+7. 不确定的资源名称不要用变量代替；如果需要先收集信息，本轮只返回 gather 为 true 的命令，收到这些命令的输出后，再使用其中的具体资源名称生成最终命令`
+
+// translatePrompt 返回命令转换的系统提示词，context 中保存了命令作用范围时一并告知模型
+func translatePrompt(ctx context.Context) string {
+	scope := ScopeFrom(ctx)
+	if scope.Context == "" && scope.Namespace == "" {
+		return translateSystemPrompt
+	}
+	return translateSystemPrompt + fmt.Sprintf("\n\n当前 kube-context 为 %s，默认命名空间为 %s。"+
+		"命令会自动在该 context 和命名空间中执行，argv 中不需要再指定 --context 和 -n，除非用户要求操作其他 context、命名空间或所有命名空间。",
+		orUnknown(scope.Context), orUnknown(scope.Namespace))
+}
+
+// orUnknown 在值为空时返回“未知”
+func orUnknown(s string) string {
+	if s == "" {
+		return "未知"
+	}
+	return s
+}
+
+// Observation 是一轮信息收集的结果：模型的回复及其中信息收集命令的输出
+type Observation struct {
+	Response string
+	Output   string
+}
+
+// TranslateCommand 将自然语言转换为 kubectl 命令，conv 不为空时带上其中的历史消息，并把本轮问答加入历史
+func (a *Assistant) TranslateCommand(ctx context.Context, conv *Conversation, naturalCommand string) (string, error) {
+	userMessage := translateUserMessage(naturalCommand)
+	messages := conv.Prompt(translatePrompt(ctx), userMessage)
+
+	// 发送请求并获取响应
+	config.Logger.WithFields(map[string]interface{}{
+		"messages_count": len(messages),
+		"enable_chat":    conv != nil,
+	}).Debug("Sending messages to LLM provider")
+	config.Logger.Debug(messages)
+
+	response, err := a.backend(config.ProfileTranslate).Chat(ctx, messages)
+	if err != nil {
+		return "", err
+	}
+
+	conv.Append(userMessage, Message{Role: "assistant", Content: response})
+	a.compact(ctx, conv)
+	return response, nil
+}
+
+// RefineCommand 将信息收集命令的输出反馈给模型，让其基于收集到的信息继续生成命令
+func (a *Assistant) RefineCommand(ctx context.Context, conv *Conversation, naturalCommand string, observations []Observation) (string, error) {
+	var messages []Message
+	if conv != nil {
+		// 历史中已有本轮的提问和之前各轮的回复，只需追加最近一轮收集到的信息
+		messages = conv.Prompt(translatePrompt(ctx), observationMessage(observations[len(observations)-1]))
+	} else {
+		messages = []Message{
+			{Role: "system", Content: translatePrompt(ctx)},
+			translateUserMessage(naturalCommand),
+		}
+		for _, obs := range observations {
+			messages = append(messages, Message{Role: "assistant", Content: obs.Response}, observationMessage(obs))
+		}
+	}
+
+	config.Logger.WithFields(map[string]interface{}{
+		"observations": len(observations),
+	}).Debug("Sending observations to LLM provider")
+
+	response, err := a.backend(config.ProfileTranslate).Chat(ctx, messages)
+	if err != nil {
+		return "", err
+	}
+
+	conv.Append(messages[len(messages)-1], Message{Role: "assistant", Content: response})
+	a.compact(ctx, conv)
+	return response, nil
+}
+
+// summarizeSystemPrompt 是压缩对话历史的系统提示词
+const summarizeSystemPrompt = `你负责压缩 Kubernetes 排障对话的历史。请把已有的摘要与新的对话合并为一份简洁的已知信息列表，保留：
+1. 涉及的 kube-context、命名空间、资源类型和资源名称
+2. 执行过的关键命令及其结论
+3. 已发现的问题、错误信息以及已经排除的可能原因
+4. 用户的目标和偏好
+每条信息一行，以 "- " 开头，不要输出其他内容；过时或被后续对话推翻的信息直接删除。`
+
+// compact 在对话历史超过摘要阈值时，把较早的消息压缩进摘要；
+// 摘要失败时保留对话不变并在下一轮重试，只有超过硬上限时才丢弃较早的消息，不影响本次请求的结果
+func (a *Assistant) compact(ctx context.Context, conv *Conversation) {
+	summary, old := conv.Overflow()
+	if len(old) == 0 {
+		return
+	}
+
+	var sb strings.Builder
+	if summary != "" {
+		fmt.Fprintf(&sb, "已有摘要：\n%s\n\n", summary)
+	}
+	sb.WriteString("新的对话：\n")
+	for _, msg := range old {
+		fmt.Fprintf(&sb, "[%s] %s\n", msg.Role, msg.Content)
+	}
+	messages := []Message{
+		{Role: "system", Content: summarizeSystemPrompt},
+		{Role: "user", Content: sb.String()},
+	}
+
+	response, err := a.backend(config.ProfileSummarize).Chat(ctx, messages)
+	if err != nil {
+		if conv.Abandon(len(old)) {
+			config.Logger.WithError(err).Warn("Failed to summarize conversation history, dropping older messages over the hard limit")
+			return
+		}
+		config.Logger.WithError(err).Warn("Failed to summarize conversation history, will retry next turn")
+		return
+	}
+	config.Logger.WithFields(map[string]interface{}{
+		"folded_messages": len(old),
+		"summary_length":  len(response),
+	}).Debug("Conversation history summarized")
+	conv.Fold(len(old), strings.TrimSpace(response))
+}
+
+// RepairCommand 将执行失败的命令和 kubectl 的错误输出发给模型，返回修正后的命令
+func (a *Assistant) RepairCommand(ctx context.Context, naturalCommand, failedCommand, errorOutput string) (string, error) {
+	messages := []Message{
+		{Role: "system", Content: translatePrompt(ctx)},
+		translateUserMessage(naturalCommand),
+		{Role: "assistant", Content: failedCommand},
+		{
+			Role:    "user",
+			Content: fmt.Sprintf("上面的命令执行失败，kubectl 输出如下：\n%s\n\n请根据错误信息修正命令，按相同的 JSON 格式只返回一条修正后的命令。", errorOutput),
+		},
+	}
+
+	config.Logger.WithFields(map[string]interface{}{
+		"failed_command": failedCommand,
+	}).Debug("Asking LLM provider to repair command")
+
+	return a.backend(config.ProfileTranslate).Chat(ctx, messages)
+}
+
+// observationMessage 创建反馈信息收集结果的用户消息
+func observationMessage(obs Observation) Message {
+	return Message{
+		Role:    "user",
+		Content: fmt.Sprintf("以下是信息收集命令的输出：\n%s\n\n如果信息已足够，请生成最终命令；否则继续返回 gather 为 true 的命令。", obs.Output),
+	}
+}
+
+// translateUserMessage 创建命令转换的用户消息
+func translateUserMessage(naturalCommand string) Message {
+	return Message{
+		Role:    "user",
+		Content: fmt.Sprintf("请将以下自然语言转换为 kubectl 命令：%s", naturalCommand),
+	}
+}
+
+// ExplainCommand 解释kuberne中yaml、api-resources等的含义
+func (a *Assistant) ExplainCommand(ctx context.Context, naturalCommand string, onDelta func(string)) (string, error) {
+	prompt := fmt.Sprintf("你是一个 Kubernetes 专家，请解释以下命令的含义。\n\n命令: %s", naturalCommand)
+	messages := []Message{
+		{
+			Role:    "system",
+			Content: "你是一个 Kubernetes 专家，专门解释命令的含义。",
+		},
+		{
+			Role:    "user",
+			Content: prompt,
+		},
+	}
+
+	return a.backend(config.ProfileExplain).Stream(ctx, messages, onDelta)
+}
@@ -0,0 +1,230 @@
+package llm
+
+import (
+	"sync"
+
+	"github.com/yourusername/kubectl-ai/pkg/budget"
+)
+
+// DefaultMaxMessages 是对话默认保留的历史消息条数，不含系统提示词
+const DefaultMaxMessages = 10
+
+// Conversation 是一次多轮对话的历史消息，由调用方创建并持有，可并发使用。
+// 系统提示词单独固定保存，截断历史时不会被丢弃。设置了摘要阈值时，
+// 超出阈值的较早消息会被压缩为“已知信息”摘要，而不是按条数直接丢弃
+type Conversation struct {
+	mu          sync.Mutex
+	system      string
+	summary     string
+	messages    []Message
+	maxMessages int
+
+	// maxTokens 是触发摘要的历史 token 数，0 表示按条数截断
+	maxTokens int
+	// compacting 表示较早的消息正在被压缩，避免并发请求重复压缩
+	compacting bool
+}
+
+// NewConversation 创建最多保留 maxMessages 条历史消息的对话，maxMessages 不大于 0 时使用 DefaultMaxMessages
+func NewConversation(maxMessages int) *Conversation {
+	if maxMessages <= 0 {
+		maxMessages = DefaultMaxMessages
+	}
+	return &Conversation{maxMessages: maxMessages}
+}
+
+// SetSummaryThreshold 设置触发摘要的历史 token 数，大于 0 时不再按条数截断历史
+func (c *Conversation) SetSummaryThreshold(maxTokens int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxTokens = maxTokens
+}
+
+// Pin 固定对话的系统提示词，之后每次请求都使用它代替调用方提供的默认提示词
+func (c *Conversation) Pin(system string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.system = system
+}
+
+// System 返回对话固定的系统提示词，未固定时为空
+func (c *Conversation) System() string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.system
+}
+
+// Summary 返回较早对话压缩后的摘要，尚未压缩时为空
+func (c *Conversation) Summary() string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.summary
+}
+
+// Restore 恢复保存的系统提示词、摘要和历史消息，用于继续之前的会话
+func (c *Conversation) Restore(system, summary string, messages []Message) {
+	c.mu.Lock()
+	c.system = system
+	c.summary = summary
+	c.messages = nil
+	c.mu.Unlock()
+	c.Append(messages...)
+}
+
+// Prompt 返回发送给模型的完整消息：系统提示词、摘要、历史消息和本轮的新消息。
+// 对话没有固定系统提示词时使用 system；c 为空时不带历史
+func (c *Conversation) Prompt(system string, newMessages ...Message) []Message {
+	if c == nil {
+		return append([]Message{{Role: "system", Content: system}}, newMessages...)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.system != "" {
+		system = c.system
+	}
+	messages := make([]Message, 0, 2+len(c.messages)+len(newMessages))
+	messages = append(messages, Message{Role: "system", Content: system})
+	if c.summary != "" {
+		messages = append(messages, Message{Role: "system", Content: "此前对话中已知的信息：\n" + c.summary})
+	}
+	messages = append(messages, c.messages...)
+	return append(messages, newMessages...)
+}
+
+// Append 将一轮对话的消息加入历史，未设置摘要阈值且超出条数上限时丢弃最早的消息；
+// 系统消息会被固定为系统提示词而不是加入历史。c 为空时不做任何事
+func (c *Conversation) Append(messages ...Message) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			c.system = msg.Content
+			continue
+		}
+		c.messages = append(c.messages, msg)
+	}
+	c.truncate()
+}
+
+// History 返回历史消息的副本，不含系统提示词
+func (c *Conversation) History() []Message {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Message(nil), c.messages...)
+}
+
+// Overflow 在历史超过摘要阈值时返回当前摘要和需要压缩的较早消息，压缩完成后必须调用 Fold，失败时调用 Abandon；
+// 最近约一半阈值的消息保留原文，且保留的部分总是以用户消息开头。
+// 未超过阈值或其他请求正在压缩时返回空
+func (c *Conversation) Overflow() (summary string, old []Message) {
+	if c == nil {
+		return "", nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.maxTokens <= 0 || c.compacting {
+		return "", nil
+	}
+
+	total := budget.EstimateTokens(c.summary)
+	for _, msg := range c.messages {
+		total += budget.EstimateTokens(msg.Content)
+	}
+	if total <= c.maxTokens {
+		return "", nil
+	}
+
+	// 从最新的消息向前保留，直到用完一半阈值；最近一条用户消息及其后的回复总是保留
+	split := c.lastUser()
+	kept := 0
+	for i := len(c.messages) - 1; i >= 0; i-- {
+		kept += budget.EstimateTokens(c.messages[i].Content)
+		if i < split && kept > c.maxTokens/2 {
+			break
+		}
+		if c.messages[i].Role == "user" {
+			split = i
+		}
+	}
+	if split <= 0 {
+		return "", nil
+	}
+	c.compacting = true
+	return c.summary, append([]Message(nil), c.messages[:split]...)
+}
+
+// Fold 用新的摘要代替最早的 n 条消息，与 Overflow 配对使用
+func (c *Conversation) Fold(n int, summary string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n > len(c.messages) {
+		n = len(c.messages)
+	}
+	c.messages = append([]Message(nil), c.messages[n:]...)
+	c.summary = summary
+	c.compacting = false
+}
+
+// Abandon 放弃本次压缩，保留原有摘要与全部消息，下一轮请求时重新尝试，与 Overflow 配对使用。
+// 只有历史超过摘要阈值的两倍时才丢弃最早的 n 条消息，避免请求超出模型的上下文；返回是否丢弃了消息
+func (c *Conversation) Abandon(n int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.compacting = false
+
+	total := budget.EstimateTokens(c.summary)
+	for _, msg := range c.messages {
+		total += budget.EstimateTokens(msg.Content)
+	}
+	if total <= 2*c.maxTokens {
+		return false
+	}
+	if n > len(c.messages) {
+		n = len(c.messages)
+	}
+	c.messages = append([]Message(nil), c.messages[n:]...)
+	return true
+}
+
+// lastUser 返回最近一条用户消息的位置，没有用户消息时返回消息条数
+func (c *Conversation) lastUser() int {
+	for i := len(c.messages) - 1; i >= 0; i-- {
+		if c.messages[i].Role == "user" {
+			return i
+		}
+	}
+	return len(c.messages)
+}
+
+// truncate 从最早的消息开始丢弃，直到不超过条数上限且历史以用户消息开头，
+// 避免留下缺少对应提问的模型回复；最近一条用户消息及其后的回复总是保留。
+// 设置了摘要阈值时由 Overflow 和 Fold 压缩历史，这里只保证历史以用户消息开头
+func (c *Conversation) truncate() {
+	last := c.lastUser()
+	maxMessages := c.maxMessages
+	if c.maxTokens > 0 {
+		maxMessages = len(c.messages)
+	}
+
+	start := 0
+	for start < last && (len(c.messages)-start > maxMessages || c.messages[start].Role != "user") {
+		start++
+	}
+	if start > 0 {
+		c.messages = append([]Message(nil), c.messages[start:]...)
+	}
+}
@@ -0,0 +1,54 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConversationAbandon(t *testing.T) {
+	tests := []struct {
+		name        string
+		size        int
+		wantDropped bool
+	}{
+		{name: "keeps history below the hard limit", size: 300},
+		{name: "drops older messages over the hard limit", size: 1000, wantDropped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conv := NewConversation(0)
+			conv.SetSummaryThreshold(100)
+			conv.Restore("", "已有摘要", []Message{
+				{Role: "user", Content: strings.Repeat("a", tt.size)},
+				{Role: "assistant", Content: strings.Repeat("b", tt.size)},
+				{Role: "user", Content: "c"},
+				{Role: "assistant", Content: "d"},
+			})
+
+			_, old := conv.Overflow()
+			if len(old) == 0 {
+				t.Fatal("Overflow() returned no messages to compact")
+			}
+			if _, again := conv.Overflow(); len(again) != 0 {
+				t.Error("Overflow() returned messages while a compaction is in progress")
+			}
+
+			if got := conv.Abandon(len(old)); got != tt.wantDropped {
+				t.Errorf("Abandon() = %v, want %v", got, tt.wantDropped)
+			}
+			if got := conv.Summary(); got != "已有摘要" {
+				t.Errorf("Summary() = %q, want the previous summary", got)
+			}
+			wantMessages := 4
+			if tt.wantDropped {
+				wantMessages = 4 - len(old)
+			}
+			if got := len(conv.messages); got != wantMessages {
+				t.Errorf("history has %d messages, want %d", got, wantMessages)
+			}
+			if _, retry := conv.Overflow(); !tt.wantDropped && len(retry) == 0 {
+				t.Error("Overflow() did not retry after Abandon()")
+			}
+		})
+	}
+}
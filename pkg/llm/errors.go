@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 按失败原因区分的错误类型，可使用 errors.Is 判断
+var (
+	// ErrAuth 表示 API Key 无效或没有权限（401/403）
+	ErrAuth = errors.New("authentication failed")
+	// ErrQuota 表示账户余额或配额不足（402，或 429 且错误码为 insufficient_quota）
+	ErrQuota = errors.New("quota exceeded")
+	// ErrRateLimited 表示请求过于频繁（429）
+	ErrRateLimited = errors.New("rate limited")
+	// ErrServer 表示服务端错误（5xx）
+	ErrServer = errors.New("server error")
+	// ErrNetwork 表示请求未能到达服务端或读取响应失败
+	ErrNetwork = errors.New("network error")
+	// ErrInvalidRequest 表示请求参数有误（400/404/422 等其他 4xx）
+	ErrInvalidRequest = errors.New("invalid request")
+)
+
+// APIError 是 API 返回非 200 状态码时的错误
+type APIError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter 是响应头 Retry-After 要求的等待时间，未设置时为 0
+	RetryAfter time.Duration
+	kind       error
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API request failed with status %d (%v): %s", e.StatusCode, e.kind, e.Body)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.kind
+}
+
+// newAPIError 根据状态码和响应内容确定错误类型
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		e.kind = ErrAuth
+	case resp.StatusCode == http.StatusPaymentRequired:
+		e.kind = ErrQuota
+	case resp.StatusCode == http.StatusTooManyRequests:
+		e.kind = ErrRateLimited
+		if strings.Contains(e.Body, "insufficient_quota") {
+			e.kind = ErrQuota
+		}
+	case resp.StatusCode >= 500:
+		e.kind = ErrServer
+	default:
+		e.kind = ErrInvalidRequest
+	}
+	return e
+}
+
+// parseRetryAfter 解析以秒数或 HTTP 日期表示的 Retry-After
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// retryable 判断错误是否可以重试：限流、服务端错误和网络错误
+func retryable(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrServer) || errors.Is(err, ErrNetwork)
+}
@@ -0,0 +1,120 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/yourusername/kubectl-ai/pkg/config"
+)
+
+// HTTPClient 是各厂商 Backend 共用的 HTTP 层：限制等待响应的时间，
+// 遇到限流、服务端错误或网络错误时按指数退避重试，并将非 200 响应转换为 APIError
+type HTTPClient struct {
+	client     *http.Client
+	timeout    time.Duration
+	maxRetries int
+	limiter    *RateLimiter
+}
+
+// NewHTTPClient 根据 Options 中的超时、重试与限流参数创建 HTTPClient，
+// Options.Limiter 为空时按 RateLimit 单独创建限流器
+func NewHTTPClient(opts Options) *HTTPClient {
+	// 流式响应的总时长不可预期，因此只限制等待响应头的时间，非流式请求的总超时在每次尝试时单独设置
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = opts.Timeout
+	limiter := opts.Limiter
+	if limiter == nil {
+		limiter = NewRateLimiter(opts.RateLimit)
+	}
+	return &HTTPClient{
+		client:     &http.Client{Transport: transport},
+		timeout:    opts.Timeout,
+		maxRetries: opts.MaxRetries,
+		limiter:    limiter,
+	}
+}
+
+// Post 发送 JSON 请求，最多重试 MaxRetries 次。
+// 返回的响应状态码总是 200，非流式请求的超时在响应 Body 关闭时释放
+func (c *HTTPClient) Post(ctx context.Context, url string, header http.Header, body []byte, stream bool) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.do(ctx, url, header, body, stream)
+		if err == nil {
+			return resp, nil
+		}
+		if !retryable(err) || attempt >= c.maxRetries || ctx.Err() != nil {
+			return nil, err
+		}
+
+		wait := backoff(attempt+1, err)
+		config.Logger.WithFields(map[string]interface{}{
+			"attempt": attempt + 1,
+			"wait":    wait.String(),
+			"error":   err.Error(),
+		}).Warn("LLM request failed, retrying")
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// do 发送一次请求，非 200 响应转换为 APIError
+func (c *HTTPClient) do(ctx context.Context, url string, header http.Header, body []byte, stream bool) (*http.Response, error) {
+	attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+	if !stream && c.timeout > 0 {
+		attemptCtx, cancel = context.WithTimeout(ctx, c.timeout)
+	}
+
+	req, err := http.NewRequestWithContext(attemptCtx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		cancel()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: failed to send request: %v", ErrNetwork, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		cancel()
+		// 添加错误响应的调试日志
+		config.Logger.WithFields(map[string]interface{}{
+			"status_code": resp.StatusCode,
+			"response":    string(data),
+		}).Debug("API request failed")
+		return nil, newAPIError(resp, data)
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose 在关闭响应 Body 时释放请求的超时 context
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
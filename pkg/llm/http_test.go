@@ -0,0 +1,108 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPClientPost(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		maxRetries int
+		wantErr    error
+		wantCalls  int32
+	}{
+		{name: "success", statuses: []int{200}, wantCalls: 1},
+		{name: "retries server error", statuses: []int{503, 200}, maxRetries: 2, wantCalls: 2},
+		{name: "gives up after max retries", statuses: []int{500, 500, 500}, maxRetries: 1, wantErr: ErrServer, wantCalls: 2},
+		{name: "does not retry auth error", statuses: []int{401, 200}, maxRetries: 2, wantErr: ErrAuth, wantCalls: 1},
+		{name: "does not retry invalid request", statuses: []int{400, 200}, maxRetries: 2, wantErr: ErrInvalidRequest, wantCalls: 1},
+		{name: "quota on 429", statuses: []int{429}, maxRetries: 2, wantErr: ErrQuota, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				if got := r.Header.Get("x-api-key"); got != "key" {
+					t.Errorf("x-api-key = %q, want %q", got, "key")
+				}
+				status := tt.statuses[n-1]
+				w.WriteHeader(status)
+				if status == http.StatusTooManyRequests {
+					io.WriteString(w, `{"error":{"code":"insufficient_quota"}}`)
+					return
+				}
+				io.WriteString(w, "ok")
+			}))
+			defer server.Close()
+
+			client := NewHTTPClient(Options{MaxRetries: tt.maxRetries})
+			header := http.Header{}
+			header.Set("x-api-key", "key")
+			resp, err := client.Post(context.Background(), server.URL, header, []byte("{}"), false)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Post() error = %v, want %v", err, tt.wantErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Post() error = %v", err)
+				}
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if string(body) != "ok" {
+					t.Errorf("Post() body = %q, want %q", body, "ok")
+				}
+			}
+			if got := atomic.LoadInt32(&calls); got != tt.wantCalls {
+				t.Errorf("server received %d requests, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestHTTPClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(Options{Timeout: 50 * time.Millisecond})
+	if _, err := client.Post(context.Background(), server.URL, nil, []byte("{}"), false); !errors.Is(err, ErrNetwork) {
+		t.Errorf("Post() error = %v, want %v", err, ErrNetwork)
+	}
+}
+
+func TestSharedRateLimiter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer server.Close()
+
+	// 两个客户端共享每分钟 600 次的限额，合计 3 个请求至少需要 2 个 100ms 的间隔
+	limiter := NewRateLimiter(600)
+	clients := []*HTTPClient{NewHTTPClient(Options{Limiter: limiter}), NewHTTPClient(Options{Limiter: limiter})}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := clients[i%2].Post(context.Background(), server.URL, nil, []byte("{}"), false)
+		if err != nil {
+			t.Fatalf("Post() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("3 requests took %v, want at least 200ms with a shared limiter", elapsed)
+	}
+}
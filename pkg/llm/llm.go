@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"context"
+	"time"
+)
+
+// Message 表示对话消息
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Options 是创建 Backend 所需的连接与采样参数
+type Options struct {
+	APIKey   string
+	Endpoint string
+	Model    string
+
+	// 采样参数，未设置时使用服务端默认值
+	Temperature *float64
+	TopP        *float64
+	MaxTokens   int
+	Seed        *int
+
+	// JSONMode 要求模型只输出 JSON 对象，用于结构化的命令转换
+	JSONMode bool
+
+	// Timeout 是等待响应的超时时间，0 表示不限制
+	Timeout time.Duration
+	// MaxRetries 是遇到限流、服务端错误或网络错误时的最大重试次数
+	MaxRetries int
+	// RateLimit 是每分钟最多发送的请求数，0 表示不限制
+	RateLimit int
+	// Limiter 是多个 Backend 共享的限流器，设置后忽略 RateLimit
+	Limiter *RateLimiter
+
+	// OnUsage 在每次请求完成后以请求的上下文、实际使用的模型名称和 token 用量调用
+	OnUsage func(ctx context.Context, model string, usage Usage)
+}
+
+// Usage 是一次请求的 token 用量，PromptTokens 包含命中缓存的部分
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	CacheHitTokens   int
+}
+
+// Backend 是具体大模型厂商 API 的抽象，只负责收发消息
+type Backend interface {
+	// Chat 发送非流式请求并返回完整回复
+	Chat(ctx context.Context, messages []Message) (string, error)
+	// Stream 发送流式请求，每收到一段内容调用一次 onDelta，最后返回完整回复
+	Stream(ctx context.Context, messages []Message, onDelta func(string)) (string, error)
+}
+
+// Provider 是命令行使用的大模型接口
+type Provider interface {
+	Backend
+	// TranslateCommand 将自然语言转换为 kubectl 命令，conv 为空时不使用多轮对话
+	TranslateCommand(ctx context.Context, conv *Conversation, naturalCommand string) (string, error)
+	// RefineCommand 基于已收集的信息继续生成命令
+	RefineCommand(ctx context.Context, conv *Conversation, naturalCommand string, observations []Observation) (string, error)
+	// RepairCommand 根据执行失败的命令及 kubectl 的错误输出生成修正后的命令
+	RepairCommand(ctx context.Context, naturalCommand, failedCommand, errorOutput string) (string, error)
+	// ExplainCommand 解释 kubectl 命令、yaml 等的含义，每收到一段内容调用一次 onDelta，最后返回完整解释
+	ExplainCommand(ctx context.Context, naturalCommand string, onDelta func(string)) (string, error)
+}
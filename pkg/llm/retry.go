@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// baseBackoff 是第一次重试前的等待时间
+	baseBackoff = 500 * time.Millisecond
+	// maxBackoff 是单次重试等待时间的上限
+	maxBackoff = 30 * time.Second
+)
+
+// backoff 返回第 attempt 次重试（从 1 开始）前的等待时间：指数增长并加入随机抖动，
+// 服务端通过 Retry-After 指定了等待时间时以其为准，但不超过 maxBackoff
+func backoff(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return min(apiErr.RetryAfter, maxBackoff)
+	}
+	d := baseBackoff << (attempt - 1)
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+	// 在 [d/2, d) 之间随机取值，避免多个客户端同时重试
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+// sleep 等待 d，context 取消时提前返回错误
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RateLimiter 限制每分钟发送的请求数，请求之间至少间隔 interval。
+// 同一提供方的所有 Backend 共享一个 RateLimiter，合计的请求数不超过限额
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// NewRateLimiter 创建每分钟最多 perMinute 个请求的限流器，perMinute 不大于 0 时不限流
+func NewRateLimiter(perMinute int) *RateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &RateLimiter{interval: time.Minute / time.Duration(perMinute)}
+}
+
+// Wait 阻塞到允许发送下一个请求
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	return sleep(ctx, time.Until(at))
+}
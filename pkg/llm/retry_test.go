@@ -0,0 +1,39 @@
+package llm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt int
+		err     error
+		min     time.Duration
+		max     time.Duration
+	}{
+		{name: "first attempt", attempt: 1, err: ErrServer, min: baseBackoff / 2, max: baseBackoff},
+		{name: "grows exponentially", attempt: 3, err: ErrServer, min: 2 * baseBackoff, max: 4 * baseBackoff},
+		{name: "capped at max backoff", attempt: 30, err: ErrServer, min: maxBackoff / 2, max: maxBackoff},
+		{name: "overflow capped", attempt: 100, err: ErrServer, min: maxBackoff / 2, max: maxBackoff},
+		{
+			name: "honors retry-after", attempt: 1,
+			err: &APIError{StatusCode: 429, RetryAfter: 3 * time.Second, kind: ErrRateLimited},
+			min: 3 * time.Second, max: 3 * time.Second,
+		},
+		{
+			name: "clamps retry-after", attempt: 1,
+			err: &APIError{StatusCode: 429, RetryAfter: time.Hour, kind: ErrRateLimited},
+			min: maxBackoff, max: maxBackoff,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := backoff(tt.attempt, tt.err)
+			if got < tt.min || got > tt.max {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", tt.attempt, got, tt.min, tt.max)
+			}
+		})
+	}
+}
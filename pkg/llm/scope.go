@@ -0,0 +1,23 @@
+package llm
+
+import "context"
+
+// scopeKey 是在 context 中保存命令作用范围的键
+type scopeKey struct{}
+
+// Scope 是生成命令时默认作用的 kube-context 与命名空间，会写入提示词
+type Scope struct {
+	Context   string
+	Namespace string
+}
+
+// WithScope 将命令默认作用的 kube-context 与命名空间保存到 context 中
+func WithScope(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFrom 返回 context 中保存的命令作用范围，未设置时为空
+func ScopeFrom(ctx context.Context) Scope {
+	scope, _ := ctx.Value(scopeKey{}).(Scope)
+	return scope
+}
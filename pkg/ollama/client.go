@@ -0,0 +1,180 @@
+package ollama
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yourusername/kubectl-ai/pkg/config"
+	"github.com/yourusername/kubectl-ai/pkg/llm"
+)
+
+const (
+	// DefaultEndpoint 是本地 Ollama 服务的聊天接口
+	DefaultEndpoint = "http://localhost:11434/api/chat"
+	// DefaultModel 是 Ollama 默认模型
+	DefaultModel = "llama3"
+)
+
+// Client 代表 Ollama API 客户端
+type Client struct {
+	endpoint   string
+	model      string
+	opts       llm.Options
+	httpClient *llm.HTTPClient
+}
+
+// NewClient 创建新的 Ollama 客户端，Ollama 不需要 API Key
+func NewClient(opts llm.Options) *Client {
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	model := opts.Model
+	if model == "" {
+		model = DefaultModel
+	}
+	return &Client{
+		endpoint:   endpoint,
+		model:      model,
+		opts:       opts,
+		httpClient: llm.NewHTTPClient(opts),
+	}
+}
+
+// Chat 发送非流式请求
+func (c *Client) Chat(ctx context.Context, messages []llm.Message) (string, error) {
+	return c.sendChatRequest(ctx, messages, false, nil)
+}
+
+// Stream 发送流式请求
+func (c *Client) Stream(ctx context.Context, messages []llm.Message, onDelta func(string)) (string, error) {
+	return c.sendChatRequest(ctx, messages, true, onDelta)
+}
+
+// sendChatRequest 发送聊天请求到 Ollama API
+func (c *Client) sendChatRequest(ctx context.Context, messages []llm.Message, stream bool, onDelta func(string)) (string, error) {
+	request := ChatRequest{
+		Model:    c.model,
+		Messages: messages,
+		Stream:   stream,
+		Options: ModelOptions{
+			Temperature: c.opts.Temperature,
+			TopP:        c.opts.TopP,
+			NumPredict:  c.opts.MaxTokens,
+			Seed:        c.opts.Seed,
+		},
+	}
+	if c.opts.JSONMode {
+		request.Format = "json"
+	}
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	config.Logger.WithFields(map[string]interface{}{
+		"request_body": string(requestBody),
+		"stream":       stream,
+	}).Debug("Sending request to Ollama API")
+
+	resp, err := c.httpClient.Post(ctx, c.endpoint, nil, requestBody, stream)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if stream {
+		// Ollama 的流式响应是每行一个 JSON 对象
+		var result strings.Builder
+		reader := bufio.NewReader(resp.Body)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return "", fmt.Errorf("%w: failed to read stream: %v", llm.ErrNetwork, err)
+			}
+
+			if trimmed := strings.TrimSpace(line); trimmed != "" {
+				var chunk ChatResponse
+				if err := json.Unmarshal([]byte(trimmed), &chunk); err != nil {
+					return "", fmt.Errorf("failed to unmarshal stream response: %v", err)
+				}
+				if onDelta != nil {
+					onDelta(chunk.Message.Content)
+				}
+				result.WriteString(chunk.Message.Content)
+				if chunk.Done {
+					c.reportUsage(ctx, chunk)
+					break
+				}
+			}
+
+			if err == io.EOF {
+				break
+			}
+		}
+		return result.String(), nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to read response: %v", llm.ErrNetwork, err)
+	}
+	config.Logger.Debug(string(body))
+
+	var response ChatResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %v", err)
+	}
+
+	c.reportUsage(ctx, response)
+	if response.Message.Content == "" {
+		return "", fmt.Errorf("no response from API")
+	}
+
+	return response.Message.Content, nil
+}
+
+// reportUsage 将最后一个响应中的 token 计数交给 OnUsage 回调
+func (c *Client) reportUsage(ctx context.Context, response ChatResponse) {
+	if c.opts.OnUsage == nil {
+		return
+	}
+	c.opts.OnUsage(ctx, c.model, llm.Usage{
+		PromptTokens:     response.PromptEvalCount,
+		CompletionTokens: response.EvalCount,
+	})
+}
+
+// ChatRequest 表示发送到 Ollama API 的请求
+type ChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []llm.Message `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Options  ModelOptions  `json:"options"`
+	// Format 为 json 时模型只输出 JSON
+	Format string `json:"format,omitempty"`
+}
+
+// ModelOptions 表示 Ollama 的采样参数
+type ModelOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+}
+
+// ChatResponse 表示 Ollama API 的响应，流式与非流式结构相同
+type ChatResponse struct {
+	Model     string      `json:"model"`
+	CreatedAt string      `json:"created_at"`
+	Message   llm.Message `json:"message"`
+	Done      bool        `json:"done"`
+	// 以下 token 计数只在最后一个响应中出现
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/yourusername/kubectl-ai/pkg/anthropic"
+	"github.com/yourusername/kubectl-ai/pkg/config"
+	"github.com/yourusername/kubectl-ai/pkg/deepseek"
+	"github.com/yourusername/kubectl-ai/pkg/llm"
+	"github.com/yourusername/kubectl-ai/pkg/ollama"
+	"github.com/yourusername/kubectl-ai/pkg/redact"
+	"github.com/yourusername/kubectl-ai/pkg/usage"
+)
+
+// NewBackend 根据提供方名称创建对应的 Backend
+func NewBackend(provider string, opts llm.Options) (llm.Backend, error) {
+	switch provider {
+	case config.ProviderDeepSeek:
+		return deepseek.NewClient(opts), nil
+	case config.ProviderOpenAI:
+		// OpenAI 与 DeepSeek 使用相同的 chat/completions 协议
+		if opts.Endpoint == "" {
+			opts.Endpoint = deepseek.OpenAIEndpoint
+		}
+		if opts.Model == "" {
+			opts.Model = deepseek.OpenAIModel
+		}
+		return deepseek.NewClient(opts), nil
+	case config.ProviderOllama:
+		return ollama.NewClient(opts), nil
+	case config.ProviderAnthropic:
+		return anthropic.NewClient(opts), nil
+	default:
+		return nil, fmt.Errorf("unsupported llm provider: %s", provider)
+	}
+}
+
+// options 将模型配置档转换为 Backend 参数，limiter 由同一提供方的所有 Backend 共享
+func options(cfg *config.Config, profile config.ModelProfile, limiter *llm.RateLimiter) llm.Options {
+	return llm.Options{
+		APIKey:      cfg.APIKey,
+		Endpoint:    profile.Endpoint,
+		Model:       profile.Model,
+		Temperature: profile.Temperature,
+		TopP:        profile.TopP,
+		MaxTokens:   profile.MaxTokens,
+		Seed:        profile.Seed,
+		Timeout:     cfg.RequestTimeout,
+		MaxRetries:  cfg.MaxRetries,
+		RateLimit:   cfg.RateLimit,
+		Limiter:     limiter,
+	}
+}
+
+// New 根据配置创建大模型 Provider，每个模型配置档对应一个独立的 Backend，所有 Backend 共享一个限流器，
+// 发往提供方的请求合计不超过 rate_limit；
+// 开启脱敏时所有 Backend 在发送前屏蔽消息中的敏感信息，tracker 不为空时统计 token 用量并检查费用上限
+func New(cfg *config.Config, tracker *usage.Tracker) (llm.Provider, error) {
+	var redactor *redact.Redactor
+	if cfg.RedactEnabled {
+		var err error
+		if redactor, err = redact.New(cfg.RedactPatterns); err != nil {
+			return nil, err
+		}
+	}
+	limiter := llm.NewRateLimiter(cfg.RateLimit)
+	newBackend := func(opts llm.Options) (llm.Backend, error) {
+		if tracker != nil {
+			opts.OnUsage = tracker.Record
+		}
+		backend, err := NewBackend(cfg.Provider, opts)
+		if err != nil {
+			return nil, err
+		}
+		if redactor != nil {
+			backend = redact.WrapBackend(backend, redactor)
+		}
+		if tracker != nil {
+			backend = usage.WrapBackend(backend, tracker)
+		}
+		return backend, nil
+	}
+
+	backend, err := newBackend(options(cfg, cfg.Model, limiter))
+	if err != nil {
+		return nil, err
+	}
+
+	assistant := llm.NewAssistant(backend)
+	for name, profile := range cfg.Profiles {
+		opts := options(cfg, profile, limiter)
+		// 命令转换要求模型返回结构化 JSON
+		opts.JSONMode = name == config.ProfileTranslate
+		backend, err := newBackend(opts)
+		if err != nil {
+			return nil, err
+		}
+		assistant.SetProfile(name, backend)
+	}
+	return assistant, nil
+}
@@ -0,0 +1,51 @@
+package redact
+
+import (
+	"context"
+
+	"github.com/yourusername/kubectl-ai/pkg/config"
+	"github.com/yourusername/kubectl-ai/pkg/llm"
+)
+
+// Backend 在消息发送给大模型之前进行脱敏
+type Backend struct {
+	llm.Backend
+	redactor *Redactor
+}
+
+// WrapBackend 返回发送前脱敏的 Backend
+func WrapBackend(backend llm.Backend, redactor *Redactor) llm.Backend {
+	return &Backend{Backend: backend, redactor: redactor}
+}
+
+// Chat 脱敏后发送非流式请求
+func (b *Backend) Chat(ctx context.Context, messages []llm.Message) (string, error) {
+	return b.Backend.Chat(ctx, b.redact(messages))
+}
+
+// Stream 脱敏后发送流式请求
+func (b *Backend) Stream(ctx context.Context, messages []llm.Message, onDelta func(string)) (string, error) {
+	return b.Backend.Stream(ctx, b.redact(messages), onDelta)
+}
+
+// redact 返回脱敏后的消息副本，并记录每条规则屏蔽的次数（不记录被屏蔽的内容）
+func (b *Backend) redact(messages []llm.Message) []llm.Message {
+	redacted := make([]llm.Message, len(messages))
+	total := map[string]int{}
+	for i, msg := range messages {
+		content, counts := b.redactor.Redact(msg.Content)
+		redacted[i] = llm.Message{Role: msg.Role, Content: content}
+		for name, n := range counts {
+			total[name] += n
+		}
+	}
+
+	if len(total) > 0 {
+		fields := make(map[string]interface{}, len(total))
+		for name, n := range total {
+			fields[name] = n
+		}
+		config.Logger.WithFields(fields).Info("Redacted sensitive data before sending to LLM provider")
+	}
+	return redacted
+}
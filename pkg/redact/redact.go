@@ -0,0 +1,173 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Mask 是替换敏感内容的占位符
+const Mask = "[REDACTED]"
+
+// rule 是一条脱敏规则，replace 返回替换后的文本及被替换的次数
+type rule struct {
+	name    string
+	replace func(text string) (string, int)
+}
+
+// Redactor 在集群数据发送给大模型之前屏蔽其中的敏感信息
+type Redactor struct {
+	rules []rule
+}
+
+// 内置规则使用的正则表达式
+var (
+	pemPattern    = regexp.MustCompile(`-----BEGIN ([A-Z0-9 ]+)-----[\s\S]*?-----END ([A-Z0-9 ]+)-----`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}`)
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]{16,}=*`)
+	base64Pattern = regexp.MustCompile(`[A-Za-z0-9+/]{40,}={0,2}`)
+	hexPattern    = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+	// credentialPattern 匹配 PASSWORD=xxx、api_key: xxx 等形式的凭据
+	credentialPattern = regexp.MustCompile(`(?i)([A-Za-z0-9_.-]*(?:password|passwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key)[A-Za-z0-9_.-]*"?[ \t]*[:=][ \t]*"?)([^\s"',}]+)`)
+	// envValuePattern 匹配 Pod 定义中名称包含凭据关键字的环境变量的 value
+	envValuePattern = regexp.MustCompile(`(?i)(name:\s*"?[A-Za-z0-9_.-]*(?:password|passwd|secret|token|api[_-]?key|access[_-]?key)[A-Za-z0-9_.-]*"?\s*\n\s*value:\s*)(\S+)`)
+
+	// secretKindPattern 判断文本中是否包含 Secret 对象
+	secretKindPattern = regexp.MustCompile(`(?m)(^\s*kind:\s*Secret\s*$|"kind":\s*"Secret")`)
+	// secretJSONDataPattern 匹配 JSON 输出中的 data 与 stringData 对象
+	secretJSONDataPattern = regexp.MustCompile(`"(data|stringData)":\s*\{[^{}]*\}`)
+	jsonValuePattern      = regexp.MustCompile(`("[^"]+":\s*)"[^"]*"`)
+	yamlDataPattern       = regexp.MustCompile(`^(\s*)(data|stringData):\s*$`)
+	yamlKeyValuePattern   = regexp.MustCompile(`^(\s*[^:\s]+:\s*)(.+)$`)
+)
+
+// New 创建包含内置规则和自定义正则的 Redactor，自定义正则匹配的整段内容会被替换
+func New(patterns []string) (*Redactor, error) {
+	r := &Redactor{
+		rules: []rule{
+			{name: "pem", replace: redactPEM},
+			{name: "secret-data", replace: redactSecretData},
+			{name: "jwt", replace: replaceAll(jwtPattern, Mask)},
+			{name: "bearer-token", replace: replaceAll(bearerPattern, "${1}"+Mask)},
+			{name: "env-credential", replace: replaceAll(envValuePattern, "${1}"+Mask)},
+			{name: "credential", replace: redactCredentials},
+			{name: "base64", replace: redactBase64},
+		},
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %v", pattern, err)
+		}
+		r.rules = append(r.rules, rule{name: "pattern:" + pattern, replace: replaceAll(re, Mask)})
+	}
+	return r, nil
+}
+
+// Redact 返回脱敏后的文本，以及每条规则替换的次数
+func (r *Redactor) Redact(text string) (string, map[string]int) {
+	counts := map[string]int{}
+	for _, rule := range r.rules {
+		var n int
+		text, n = rule.replace(text)
+		if n > 0 {
+			counts[rule.name] += n
+		}
+	}
+	return text, counts
+}
+
+// replaceAll 返回按正则替换并计数的规则，替换模板中 ${1} 表示保留的前缀
+func replaceAll(re *regexp.Regexp, template string) func(string) (string, int) {
+	return func(text string) (string, int) {
+		n := 0
+		text = re.ReplaceAllStringFunc(text, func(match string) string {
+			replaced := re.ReplaceAllString(match, template)
+			if replaced != match {
+				n++
+			}
+			return replaced
+		})
+		return text, n
+	}
+}
+
+// redactCredentials 屏蔽凭据键对应的值，secretName、tokenRef 等引用其他对象名称的键除外
+func redactCredentials(text string) (string, int) {
+	n := 0
+	text = credentialPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := credentialPattern.FindStringSubmatch(match)
+		key := strings.ToLower(strings.TrimRight(m[1], "\" :=\t"))
+		if strings.HasSuffix(key, "name") || strings.HasSuffix(key, "ref") || m[2] == Mask {
+			return match
+		}
+		n++
+		return m[1] + Mask
+	})
+	return text, n
+}
+
+// redactPEM 保留 PEM 块的首尾标记，屏蔽其中的证书或私钥内容
+func redactPEM(text string) (string, int) {
+	n := 0
+	text = pemPattern.ReplaceAllStringFunc(text, func(match string) string {
+		n++
+		m := pemPattern.FindStringSubmatch(match)
+		return fmt.Sprintf("-----BEGIN %s-----\n%s\n-----END %s-----", m[1], Mask, m[2])
+	})
+	return text, n
+}
+
+// redactBase64 屏蔽较长的 base64 字符串，如 kubeconfig 中的证书数据；纯十六进制的镜像摘要等不屏蔽
+func redactBase64(text string) (string, int) {
+	n := 0
+	text = base64Pattern.ReplaceAllStringFunc(text, func(match string) string {
+		// 随机数据的 base64 编码几乎总是同时包含大小写字母和数字，以此排除 API 路径等普通文本
+		if hexPattern.MatchString(match) || !strings.ContainsAny(match, "0123456789") ||
+			!strings.ContainsAny(match, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") || !strings.ContainsAny(match, "abcdefghijklmnopqrstuvwxyz") {
+			return match
+		}
+		n++
+		return Mask
+	})
+	return text, n
+}
+
+// redactSecretData 屏蔽 Secret 对象 data 与 stringData 中的所有值，同时支持 YAML 与 JSON 输出
+func redactSecretData(text string) (string, int) {
+	if !secretKindPattern.MatchString(text) {
+		return text, 0
+	}
+
+	n := 0
+	text = secretJSONDataPattern.ReplaceAllStringFunc(text, func(block string) string {
+		return jsonValuePattern.ReplaceAllStringFunc(block, func(kv string) string {
+			n++
+			return jsonValuePattern.ReplaceAllString(kv, `${1}"`+Mask+`"`)
+		})
+	})
+
+	// YAML 中 data: 之后缩进更深的行都是 Secret 的键值
+	lines := strings.Split(text, "\n")
+	dataIndent := -1
+	for i, line := range lines {
+		if m := yamlDataPattern.FindStringSubmatch(line); m != nil {
+			dataIndent = len(m[1])
+			continue
+		}
+		if dataIndent < 0 {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if strings.TrimSpace(line) == "" || indent <= dataIndent {
+			dataIndent = -1
+			continue
+		}
+		if m := yamlKeyValuePattern.FindStringSubmatch(line); m != nil {
+			lines[i] = m[1] + Mask
+			n++
+		}
+	}
+	return strings.Join(lines, "\n"), n
+}
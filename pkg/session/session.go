@@ -0,0 +1,260 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/kubectl-ai/pkg/config"
+	"github.com/yourusername/kubectl-ai/pkg/llm"
+)
+
+// ErrNotFound 表示不存在指定 ID 的会话
+var ErrNotFound = errors.New("session not found")
+
+// Execution 是会话中执行的一条 kubectl 命令及其输出
+type Execution struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Output  string    `json:"output,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Turn 是会话中的一次输入，以及由它产生的命令执行和最终输出
+type Turn struct {
+	Time       time.Time   `json:"time"`
+	Input      string      `json:"input"`
+	Executions []Execution `json:"executions,omitempty"`
+	Output     string      `json:"output,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// Record 记录本次输入执行的一条命令，可作为 Agent 的命令观察者
+func (t *Turn) Record(command, output string, err error) {
+	exec := Execution{Time: time.Now(), Command: command, Output: output}
+	if err != nil {
+		exec.Error = err.Error()
+	}
+	t.Executions = append(t.Executions, exec)
+}
+
+// Finish 记录本次输入的最终输出或错误
+func (t *Turn) Finish(output string, err error) {
+	t.Output = output
+	if err != nil {
+		t.Error = err.Error()
+	}
+}
+
+// Session 是保存在本地、可以恢复的一次交互式会话：包括与模型的对话历史以及每次输入执行的命令和输出
+type Session struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+	// System、Summary 与 Messages 是对话固定的系统提示词、较早对话的摘要和历史消息，未开启多轮对话时为空
+	System   string        `json:"system,omitempty"`
+	Summary  string        `json:"summary,omitempty"`
+	Messages []llm.Message `json:"messages,omitempty"`
+	Turns    []*Turn       `json:"turns"`
+
+	conv *llm.Conversation
+}
+
+// New 创建新的会话
+func New() *Session {
+	b := make([]byte, 3)
+	rand.Read(b)
+	now := time.Now()
+	return &Session{
+		ID:      now.Format("20060102-150405") + "-" + hex.EncodeToString(b),
+		Created: now,
+		Updated: now,
+	}
+}
+
+// Attach 将会话保存的对话历史恢复到 conv 中，之后保存会话时记录 conv 的内容
+func (s *Session) Attach(conv *llm.Conversation) {
+	conv.Restore(s.System, s.Summary, s.Messages)
+	s.conv = conv
+}
+
+// Begin 开始记录一次新的输入
+func (s *Session) Begin(input string) *Turn {
+	turn := &Turn{Time: time.Now(), Input: input}
+	s.Turns = append(s.Turns, turn)
+	return turn
+}
+
+// Title 返回会话的第一条输入，用于列表展示
+func (s *Session) Title() string {
+	if len(s.Turns) == 0 {
+		return ""
+	}
+	return s.Turns[0].Input
+}
+
+// LastCommand 返回会话中最近执行的一条命令，没有执行过命令时为空
+func (s *Session) LastCommand() string {
+	for i := len(s.Turns) - 1; i >= 0; i-- {
+		if executions := s.Turns[i].Executions; len(executions) > 0 {
+			return executions[len(executions)-1].Command
+		}
+	}
+	return ""
+}
+
+// Store 将会话以 JSON 文件的形式保存在本地目录中，每个会话一个文件
+type Store struct {
+	dir string
+}
+
+// NewStore 创建保存在 dataDir/sessions 目录下的会话存储
+func NewStore(dataDir string) *Store {
+	return &Store{dir: filepath.Join(dataDir, "sessions")}
+}
+
+// Save 保存会话
+func (st *Store) Save(s *Session) error {
+	if err := os.MkdirAll(st.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create session directory: %v", err)
+	}
+	return s.WriteFile(st.path(s.ID))
+}
+
+// WriteFile 将会话写入指定文件，先写入临时文件再重命名，避免中断时留下不完整的文件
+func (s *Session) WriteFile(path string) error {
+	if s.conv != nil {
+		s.System = s.conv.System()
+		s.Summary = s.conv.Summary()
+		s.Messages = s.conv.History()
+	}
+	s.Updated = time.Now()
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write session: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write session: %v", err)
+	}
+	return nil
+}
+
+// Load 读取指定 ID 的会话，id 可以是能唯一确定会话的前缀
+func (st *Store) Load(id string) (*Session, error) {
+	id, err := st.resolve(id)
+	if err != nil {
+		return nil, err
+	}
+	return st.load(id)
+}
+
+// load 读取完整 ID 对应的会话文件
+func (st *Store) load(id string) (*Session, error) {
+	data, err := os.ReadFile(st.path(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %v", err)
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse session %s: %v", id, err)
+	}
+	return &s, nil
+}
+
+// List 按最近更新时间从新到旧返回全部会话，无法读取的会话文件记录警告后跳过
+func (st *Store) List() ([]*Session, error) {
+	ids, err := st.ids()
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]*Session, 0, len(ids))
+	for _, id := range ids {
+		s, err := st.load(id)
+		if err != nil {
+			config.Logger.WithError(err).WithFields(map[string]interface{}{
+				"session": id,
+			}).Warn("Skipping unreadable session file")
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Updated.After(sessions[j].Updated)
+	})
+	return sessions, nil
+}
+
+// Delete 删除指定 ID 的会话，返回被删除会话的完整 ID
+func (st *Store) Delete(id string) (string, error) {
+	id, err := st.resolve(id)
+	if err != nil {
+		return "", err
+	}
+	if err := os.Remove(st.path(id)); err != nil {
+		return "", fmt.Errorf("failed to delete session: %v", err)
+	}
+	return id, nil
+}
+
+// resolve 将完整 ID 或唯一前缀解析为完整 ID，空前缀不匹配任何会话
+func (st *Store) resolve(id string) (string, error) {
+	if id == "" {
+		return "", fmt.Errorf("%w: empty session id", ErrNotFound)
+	}
+	ids, err := st.ids()
+	if err != nil {
+		return "", err
+	}
+	var matches []string
+	for _, candidate := range ids {
+		if candidate == id {
+			return id, nil
+		}
+		if strings.HasPrefix(candidate, id) {
+			matches = append(matches, candidate)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: %s", ErrNotFound, id)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("session id %s is ambiguous: %s", id, strings.Join(matches, ", "))
+	}
+}
+
+// ids 返回目录中全部会话的 ID
+func (st *Store) ids() ([]string, error) {
+	entries, err := os.ReadDir(st.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session directory: %v", err)
+	}
+	var ids []string
+	for _, entry := range entries {
+		if name := entry.Name(); !entry.IsDir() && strings.HasSuffix(name, ".json") {
+			ids = append(ids, strings.TrimSuffix(name, ".json"))
+		}
+	}
+	return ids, nil
+}
+
+// path 返回会话文件的路径
+func (st *Store) path(id string) string {
+	return filepath.Join(st.dir, id+".json")
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	dataDir := t.TempDir()
+	store := NewStore(dataDir)
+	older, newer := New(), New()
+	older.ID, newer.ID = "20260101-000000-aaaaaa", "20260102-000000-bbbbbb"
+	newer.Updated = older.Updated.Add(time.Hour)
+	for _, s := range []*Session{older, newer} {
+		if err := store.Save(s); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, "sessions", "20260103-000000-cccccc.json"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("list skips corrupt files", func(t *testing.T) {
+		sessions, err := store.List()
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		if len(sessions) != 2 || sessions[0].ID != newer.ID || sessions[1].ID != older.ID {
+			t.Errorf("List() returned %d sessions, want %s then %s", len(sessions), newer.ID, older.ID)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		id      string
+		want    string
+		wantErr error
+	}{
+		{name: "full id", id: older.ID, want: older.ID},
+		{name: "unique prefix", id: "20260102", want: newer.ID},
+		{name: "empty id", id: "", wantErr: ErrNotFound},
+		{name: "unknown id", id: "2025", wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := store.Load(tt.id)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Load(%q) error = %v, want %v", tt.id, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load(%q) error = %v", tt.id, err)
+			}
+			if s.ID != tt.want {
+				t.Errorf("Load(%q) = %s, want %s", tt.id, s.ID, tt.want)
+			}
+		})
+	}
+
+	if _, err := store.Load("2026010"); err == nil {
+		t.Error("Load() with an ambiguous prefix succeeded")
+	}
+}
@@ -0,0 +1,34 @@
+package usage
+
+import (
+	"context"
+
+	"github.com/yourusername/kubectl-ai/pkg/llm"
+)
+
+// Backend 在每次请求前检查本月费用是否已达到上限
+type Backend struct {
+	llm.Backend
+	tracker *Tracker
+}
+
+// WrapBackend 返回发送请求前检查费用上限的 Backend
+func WrapBackend(backend llm.Backend, tracker *Tracker) llm.Backend {
+	return &Backend{Backend: backend, tracker: tracker}
+}
+
+// Chat 检查费用上限后发送非流式请求
+func (b *Backend) Chat(ctx context.Context, messages []llm.Message) (string, error) {
+	if err := b.tracker.Check(); err != nil {
+		return "", err
+	}
+	return b.Backend.Chat(ctx, messages)
+}
+
+// Stream 检查费用上限后发送流式请求
+func (b *Backend) Stream(ctx context.Context, messages []llm.Message, onDelta func(string)) (string, error) {
+	if err := b.tracker.Check(); err != nil {
+		return "", err
+	}
+	return b.Backend.Stream(ctx, messages, onDelta)
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package usage
+
+// lockFile 在不支持 flock 的平台上不加文件锁，只依赖 Tracker 的进程内互斥
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,256 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/kubectl-ai/pkg/config"
+	"github.com/yourusername/kubectl-ai/pkg/llm"
+)
+
+// ErrSpendCapExceeded 表示本月费用已达到上限
+var ErrSpendCapExceeded = errors.New("monthly spend cap exceeded")
+
+// Totals 是累计的请求数、token 用量与费用
+type Totals struct {
+	Requests         int     `json:"requests" yaml:"requests"`
+	PromptTokens     int     `json:"prompt_tokens" yaml:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens" yaml:"completion_tokens"`
+	CacheHitTokens   int     `json:"cache_hit_tokens" yaml:"cache_hit_tokens"`
+	Cost             float64 `json:"cost" yaml:"cost"`
+}
+
+// add 累加一次请求的用量与费用
+func (t *Totals) add(u llm.Usage, cost float64) {
+	t.Requests++
+	t.PromptTokens += u.PromptTokens
+	t.CompletionTokens += u.CompletionTokens
+	t.CacheHitTokens += u.CacheHitTokens
+	t.Cost += cost
+}
+
+// counterKey 是 Counter 在 context 中的键
+type counterKey struct{}
+
+// Counter 统计同一个 context 中发起的模型请求的用量，用于单独统计并发执行的每个输入
+type Counter struct {
+	mu     sync.Mutex
+	totals Totals
+}
+
+// WithCounter 返回带有新 Counter 的 context，之后使用该 context 的请求都会计入这个 Counter
+func WithCounter(ctx context.Context) (context.Context, *Counter) {
+	counter := &Counter{}
+	return context.WithValue(ctx, counterKey{}, counter), counter
+}
+
+// Totals 返回目前累计的用量与费用
+func (c *Counter) Totals() Totals {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.totals
+}
+
+// add 累加一次请求的用量与费用
+func (c *Counter) add(u llm.Usage, cost float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.totals.add(u, cost)
+}
+
+// ledger 是保存在本地的当月累计用量
+type ledger struct {
+	Month string `json:"month"`
+	Totals
+}
+
+// Tracker 统计本次会话和本月的 token 用量与费用
+type Tracker struct {
+	mu         sync.Mutex
+	path       string
+	prices     map[string]config.ModelPrice
+	monthlyCap float64
+
+	session Totals
+	models  map[string]*Totals
+}
+
+// NewTracker 创建用量统计，当月累计用量保存在 dataDir/usage.json
+func NewTracker(dataDir string, prices map[string]config.ModelPrice, monthlyCap float64) *Tracker {
+	return &Tracker{
+		path:       filepath.Join(dataDir, "usage.json"),
+		prices:     prices,
+		monthlyCap: monthlyCap,
+		models:     make(map[string]*Totals),
+	}
+}
+
+// Cost 按配置的价格计算一次请求的费用，未配置价格的模型费用为 0
+func (t *Tracker) Cost(model string, u llm.Usage) float64 {
+	price, ok := t.prices[model]
+	if !ok {
+		return 0
+	}
+	cacheHitPrice := price.CacheHit
+	if cacheHitPrice == 0 {
+		cacheHitPrice = price.Prompt
+	}
+	return (float64(u.PromptTokens-u.CacheHitTokens)*price.Prompt +
+		float64(u.CacheHitTokens)*cacheHitPrice +
+		float64(u.CompletionTokens)*price.Completion) / 1e6
+}
+
+// Record 记录一次请求的用量，累加到本次会话和本月的统计中；ctx 中带有 Counter 时同时累加到该 Counter
+func (t *Tracker) Record(ctx context.Context, model string, u llm.Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cost := t.Cost(model, u)
+	t.session.add(u, cost)
+	if counter, ok := ctx.Value(counterKey{}).(*Counter); ok {
+		counter.add(u, cost)
+	}
+	if t.models[model] == nil {
+		t.models[model] = &Totals{}
+	}
+	t.models[model].add(u, cost)
+
+	config.Logger.WithFields(map[string]interface{}{
+		"model":             model,
+		"prompt_tokens":     u.PromptTokens,
+		"completion_tokens": u.CompletionTokens,
+		"cache_hit_tokens":  u.CacheHitTokens,
+		"cost":              cost,
+	}).Debug("LLM request usage")
+
+	if err := t.update(func(month *ledger) { month.add(u, cost) }); err != nil {
+		config.Logger.WithError(err).Warn("Failed to update usage ledger")
+	}
+}
+
+// update 在文件锁内读取、修改并保存当月累计用量，避免多个进程同时记录时丢失更新
+func (t *Tracker) update(fn func(*ledger)) error {
+	if err := os.MkdirAll(filepath.Dir(t.path), 0700); err != nil {
+		return fmt.Errorf("failed to create usage ledger directory: %v", err)
+	}
+	unlock, err := lockFile(t.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	month, err := t.load()
+	if err != nil {
+		return err
+	}
+	fn(month)
+	return t.save(month)
+}
+
+// Check 在本月费用达到上限时返回 ErrSpendCapExceeded
+func (t *Tracker) Check() error {
+	if t.monthlyCap <= 0 {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	month, err := t.load()
+	if err != nil {
+		return err
+	}
+	if month.Cost >= t.monthlyCap {
+		return fmt.Errorf("%w: spent %.4f of %.4f in %s", ErrSpendCapExceeded, month.Cost, t.monthlyCap, month.Month)
+	}
+	return nil
+}
+
+// Session 返回本次会话累计的用量与费用
+func (t *Tracker) Session() Totals {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.session
+}
+
+// Summary 返回本次会话按模型汇总的用量与费用，以及本月累计费用
+func (t *Tracker) Summary() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "本次会话共 %d 次请求，输入 %d tokens（命中缓存 %d），输出 %d tokens，费用 %.4f\n",
+		t.session.Requests, t.session.PromptTokens, t.session.CacheHitTokens, t.session.CompletionTokens, t.session.Cost)
+
+	models := make([]string, 0, len(t.models))
+	for model := range t.models {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		m := t.models[model]
+		fmt.Fprintf(&sb, "  %s: %d 次请求，输入 %d，输出 %d，费用 %.4f\n",
+			model, m.Requests, m.PromptTokens, m.CompletionTokens, m.Cost)
+	}
+
+	if month, err := t.load(); err == nil {
+		fmt.Fprintf(&sb, "本月（%s）累计费用 %.4f", month.Month, month.Cost)
+		if t.monthlyCap > 0 {
+			fmt.Fprintf(&sb, "，上限 %.4f", t.monthlyCap)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// load 读取当月累计用量，月份变化时从零开始
+func (t *Tracker) load() (*ledger, error) {
+	current := time.Now().Format("2006-01")
+	data, err := os.ReadFile(t.path)
+	if os.IsNotExist(err) {
+		return &ledger{Month: current}, nil
+	}
+	if err != nil {
+		return &ledger{Month: current}, fmt.Errorf("failed to read usage ledger: %v", err)
+	}
+
+	var l ledger
+	if err := json.Unmarshal(data, &l); err != nil {
+		return &ledger{Month: current}, fmt.Errorf("failed to parse usage ledger: %v", err)
+	}
+	if l.Month != current {
+		return &ledger{Month: current}, nil
+	}
+	return &l, nil
+}
+
+// save 保存当月累计用量：先写入临时文件再重命名，读取方不会看到写了一半的文件
+func (t *Tracker) save(l *ledger) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(t.path), ".usage-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create usage ledger: %v", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write usage ledger: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write usage ledger: %v", err)
+	}
+	if err := os.Rename(f.Name(), t.path); err != nil {
+		return fmt.Errorf("failed to save usage ledger: %v", err)
+	}
+	return nil
+}
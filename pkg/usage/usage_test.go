@@ -0,0 +1,88 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/yourusername/kubectl-ai/pkg/config"
+	"github.com/yourusername/kubectl-ai/pkg/llm"
+)
+
+var testPrices = map[string]config.ModelPrice{
+	"chat":    {Prompt: 2, Completion: 8, CacheHit: 0.5},
+	"nocache": {Prompt: 2, Completion: 8},
+}
+
+func TestTrackerCost(t *testing.T) {
+	tests := []struct {
+		name  string
+		model string
+		usage llm.Usage
+		want  float64
+	}{
+		{name: "unknown model", model: "other", usage: llm.Usage{PromptTokens: 1000, CompletionTokens: 1000}, want: 0},
+		{name: "prompt and completion", model: "chat", usage: llm.Usage{PromptTokens: 1e6, CompletionTokens: 5e5}, want: 6},
+		{name: "cache hits use cache price", model: "chat", usage: llm.Usage{PromptTokens: 1e6, CacheHitTokens: 4e5}, want: 1.4},
+		{name: "cache price defaults to prompt price", model: "nocache", usage: llm.Usage{PromptTokens: 1e6, CacheHitTokens: 4e5}, want: 2},
+	}
+	tracker := NewTracker(t.TempDir(), testPrices, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tracker.Cost(tt.model, tt.usage); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Cost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrackerCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		cap     float64
+		records int
+		wantErr bool
+	}{
+		{name: "no cap", cap: 0, records: 3},
+		{name: "below cap", cap: 5, records: 2},
+		{name: "reaches cap", cap: 4, records: 2, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewTracker(t.TempDir(), testPrices, tt.cap)
+			for i := 0; i < tt.records; i++ {
+				// 每次请求费用为 2
+				tracker.Record(context.Background(), "chat", llm.Usage{PromptTokens: 1e6})
+			}
+			err := tracker.Check()
+			if got := errors.Is(err, ErrSpendCapExceeded); got != tt.wantErr {
+				t.Errorf("Check() error = %v, want cap exceeded %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTrackerRecordConcurrent(t *testing.T) {
+	// 多个 Tracker 共用同一份用量文件，模拟多个进程同时记录
+	dir := t.TempDir()
+	trackers := []*Tracker{NewTracker(dir, testPrices, 0), NewTracker(dir, testPrices, 0)}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(tracker *Tracker) {
+			defer wg.Done()
+			tracker.Record(context.Background(), "chat", llm.Usage{PromptTokens: 1000})
+		}(trackers[i%2])
+	}
+	wg.Wait()
+
+	month, err := trackers[0].load()
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if month.Requests != 20 {
+		t.Errorf("ledger has %d requests, want 20", month.Requests)
+	}
+}
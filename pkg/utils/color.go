@@ -2,24 +2,40 @@ package utils
 
 import "fmt"
 
+// colorEnabled 表示是否输出 ANSI 颜色
+var colorEnabled = true
+
+// SetColor 设置是否输出 ANSI 颜色，关闭后各颜色函数原样返回文本
+func SetColor(enabled bool) {
+	colorEnabled = enabled
+}
+
+// colorize 使用指定的 ANSI 颜色代码包裹文本
+func colorize(code, text string) string {
+	if !colorEnabled {
+		return text
+	}
+	return fmt.Sprintf("\x1b[%sm%s\x1b[0m", code, text)
+}
+
 // Blue 返回蓝色文本
 func Blue(text string) string {
-	return fmt.Sprintf("\x1b[34m%s\x1b[0m", text)
+	return colorize("34", text)
 }
 
 // Green 返回绿色文本
 func Green(text string) string {
-	return fmt.Sprintf("\x1b[32m%s\x1b[0m", text)
+	return colorize("32", text)
 }
 
 // Yellow 返回黄色文本
 func Yellow(text string) string {
-	return fmt.Sprintf("\x1b[33m%s\x1b[0m", text)
+	return colorize("33", text)
 }
 
 // Red 返回红色文本
 func Red(text string) string {
-	return fmt.Sprintf("\x1b[31m%s\x1b[0m", text)
+	return colorize("31", text)
 }
 
 // FormatCommand 格式化命令显示
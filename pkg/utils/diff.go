@@ -0,0 +1,220 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffOp 表示差异中的一个片段：' ' 相同，'-' 删除，'+' 新增
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// maxDiffCells 是最长公共子序列表的最大单元数，超过时不再计算最小差异，
+// 避免对比大对象时分配过多内存
+const maxDiffCells = 1 << 22
+
+// diff 基于最长公共子序列计算 a 到 b 的差异。相同的开头与结尾不参与计算，
+// 其余部分过大时退化为删除全部旧行、新增全部新行
+func diff(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// diffMiddle 计算去掉相同开头与结尾后的差异
+func diffMiddle(a, b []string) []diffOp {
+	var ops []diffOp
+	if (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] 表示 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// DiffCommand 按单词对比两条命令，返回两行带颜色的差异：
+// 原命令中被删除的单词标红，新命令中新增的单词标绿
+func DiffCommand(oldCmd, newCmd string) string {
+	var oldLine, newLine []string
+	for _, op := range diff(strings.Fields(oldCmd), strings.Fields(newCmd)) {
+		switch op.kind {
+		case '-':
+			oldLine = append(oldLine, Red(op.text))
+		case '+':
+			newLine = append(newLine, Green(op.text))
+		default:
+			oldLine = append(oldLine, op.text)
+			newLine = append(newLine, op.text)
+		}
+	}
+	return Red("- ") + strings.Join(oldLine, " ") + "\n" + Green("+ ") + strings.Join(newLine, " ")
+}
+
+// UnifiedDiff 按行对比两段文本，返回带颜色的统一格式差异，context 为每处修改前后保留的相同行数；
+// 两段文本相同时返回空字符串
+func UnifiedDiff(oldName, newName, oldText, newText string, context int) string {
+	ops := diff(splitLines(oldText), splitLines(newText))
+
+	// 找出需要输出的行：修改的行及其前后 context 行
+	show := make([]bool, len(ops))
+	changed := false
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		changed = true
+		for j := i - context; j <= i+context; j++ {
+			if j >= 0 && j < len(ops) {
+				show[j] = true
+			}
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(Red("--- "+oldName) + "\n")
+	sb.WriteString(Green("+++ "+newName) + "\n")
+
+	// oldLine 与 newLine 是当前位置在原文本与新文本中的行号（从 1 开始）
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(ops); {
+		if !show[i] {
+			if ops[i].kind != '+' {
+				oldLine++
+			}
+			if ops[i].kind != '-' {
+				newLine++
+			}
+			i++
+			continue
+		}
+
+		// 收集一个连续的片段
+		end := i
+		oldCount, newCount := 0, 0
+		for ; end < len(ops) && show[end]; end++ {
+			if ops[end].kind != '+' {
+				oldCount++
+			}
+			if ops[end].kind != '-' {
+				newCount++
+			}
+		}
+		sb.WriteString(Blue(fmt.Sprintf("@@ -%s +%s @@", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))) + "\n")
+		for ; i < end; i++ {
+			switch ops[i].kind {
+			case '-':
+				sb.WriteString(Red("-"+ops[i].text) + "\n")
+			case '+':
+				sb.WriteString(Green("+"+ops[i].text) + "\n")
+			default:
+				sb.WriteString(" " + ops[i].text + "\n")
+			}
+		}
+		oldLine += oldCount
+		newLine += newCount
+	}
+	return sb.String()
+}
+
+// ColorizeDiff 为已有的统一格式差异（如 kubectl diff 的输出）添加颜色
+func ColorizeDiff(text string) string {
+	var sb strings.Builder
+	for _, line := range splitLines(text) {
+		switch {
+		case strings.HasPrefix(line, "-"):
+			line = Red(line)
+		case strings.HasPrefix(line, "+"):
+			line = Green(line)
+		case strings.HasPrefix(line, "@@"):
+			line = Blue(line)
+		case strings.HasPrefix(line, "diff "):
+			line = Yellow(line)
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+// hunkRange 返回统一格式差异中片段的行号范围
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// 空片段的起始行号为前一行
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines 将文本拆分为行，忽略末尾的换行符
+func splitLines(text string) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
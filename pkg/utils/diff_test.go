@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	SetColor(false)
+	defer SetColor(true)
+
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		context int
+		want    string
+	}{
+		{name: "identical", old: "a\nb\n", new: "a\nb\n", context: 3, want: ""},
+		{
+			name:    "changed line",
+			old:     "a\nb\nc\n",
+			new:     "a\nB\nc\n",
+			context: 1,
+			want:    "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:    "context limits hunk",
+			old:     "1\n2\n3\n4\n5\n6\n7\n",
+			new:     "1\n2\n3\nx\n5\n6\n7\n",
+			context: 1,
+			want:    "--- old\n+++ new\n@@ -3,3 +3,3 @@\n 3\n-4\n+x\n 5\n",
+		},
+		{
+			name:    "added to empty",
+			old:     "",
+			new:     "a\n",
+			context: 3,
+			want:    "--- old\n+++ new\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name:    "removed all",
+			old:     "a\nb\n",
+			new:     "",
+			context: 3,
+			want:    "--- old\n+++ new\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name:    "two hunks",
+			old:     "a\n1\n2\n3\n4\nb\n",
+			new:     "A\n1\n2\n3\n4\nB\n",
+			context: 1,
+			want:    "--- old\n+++ new\n@@ -1,2 +1,2 @@\n-a\n+A\n 1\n@@ -5,2 +5,2 @@\n 4\n-b\n+B\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnifiedDiff("old", "new", tt.old, tt.new, tt.context); got != tt.want {
+				t.Errorf("UnifiedDiff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiffLargeInput(t *testing.T) {
+	SetColor(false)
+	defer SetColor(true)
+
+	// 中间部分超过 maxDiffCells 时退化为整体替换，相同的开头与结尾仍作为上下文
+	var oldLines, newLines []string
+	for i := 0; i < 5000; i++ {
+		oldLines = append(oldLines, fmt.Sprintf("old-%d", i))
+		newLines = append(newLines, fmt.Sprintf("new-%d", i))
+	}
+	old := "head\n" + strings.Join(oldLines, "\n") + "\ntail\n"
+	new := "head\n" + strings.Join(newLines, "\n") + "\ntail\n"
+
+	got := UnifiedDiff("old", "new", old, new, 1)
+	if !strings.HasPrefix(got, "--- old\n+++ new\n@@ -1,5002 +1,5002 @@\n head\n-old-0\n") {
+		t.Errorf("UnifiedDiff() starts with %q", got[:60])
+	}
+	if !strings.HasSuffix(got, "+new-4999\n tail\n") {
+		t.Errorf("UnifiedDiff() ends with %q", got[len(got)-40:])
+	}
+}
+
+func TestColorizeDiff(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "removed", input: "-a\n", want: Red("-a") + "\n"},
+		{name: "added", input: "+a\n", want: Green("+a") + "\n"},
+		{name: "hunk header", input: "@@ -1 +1 @@\n", want: Blue("@@ -1 +1 @@") + "\n"},
+		{name: "file header", input: "diff -u a b\n", want: Yellow("diff -u a b") + "\n"},
+		{name: "context", input: " a\n", want: " a\n"},
+		{name: "empty", input: "", want: ""},
+		{name: "missing trailing newline", input: "-a\n+b", want: Red("-a") + "\n" + Green("+b") + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ColorizeDiff(tt.input); got != tt.want {
+				t.Errorf("ColorizeDiff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}